@@ -0,0 +1,36 @@
+package blackjack
+
+import "discordgo-blackjack/cards"
+
+// Hand is one of the player's hands, splitting a pair adds another
+type Hand struct {
+	Cards   []cards.Card
+	Bet     int  // Credits staked on this hand
+	Split   bool // Hand was created by splitting a pair
+	Doubled bool // Player doubled down and drew their one card
+	Done    bool // Player has finished acting on this hand
+}
+
+// Result evaluates the hand, a two card 21 only counts as a blackjack if the hand wasn't split
+func (hand *Hand) Result() cards.HandResult {
+	if hand.Split {
+		return cards.EvaluateSplit(hand.Cards)
+	}
+
+	return cards.Evaluate(hand.Cards)
+}
+
+// Value returns the best value of the hand
+func (hand *Hand) Value() int {
+	return cards.HandValue(hand.Cards)
+}
+
+// IsBust returns true if the hand is over 21
+func (hand *Hand) IsBust() bool {
+	return cards.IsBust(hand.Cards)
+}
+
+// IsPair returns true if the hand is two cards of the same rank
+func (hand *Hand) IsPair() bool {
+	return len(hand.Cards) == 2 && hand.Cards[0].Rank == hand.Cards[1].Rank
+}
@@ -0,0 +1,20 @@
+package blackjack
+
+import (
+	"discordgo-blackjack/cards"
+	"testing"
+)
+
+func TestHandResultOnlyCountsNaturals(t *testing.T) {
+	for _, ten := range []cards.Rank{cards.Ten, cards.Jack, cards.Queen, cards.King} {
+		hand := Hand{Cards: []cards.Card{{Rank: cards.Ace, Suit: cards.Spades}, {Rank: ten, Suit: cards.Hearts}}}
+		if !hand.Result().Blackjack {
+			t.Errorf("Ace %s is not a blackjack", ten)
+		}
+
+		hand.Split = true
+		if result := hand.Result(); result.Blackjack || result.Total != 21 {
+			t.Errorf("split Ace %s = %+v, want a plain 21", ten, result)
+		}
+	}
+}
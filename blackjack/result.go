@@ -0,0 +1,79 @@
+package blackjack
+
+import "discordgo-blackjack/cards"
+
+// Outcome is how a round ended
+type Outcome int
+
+const (
+	Pending Outcome = iota
+	PlayerBlackjack
+	DealerBlackjack
+	PlayerBust
+	DealerBust
+	PlayerWin
+	DealerWin
+	Push
+	EvenMoney   // Player took 1:1 on a blackjack against a dealer ace
+	Surrendered // Player gave up the hand for half their bet back
+	Forfeited   // Player left the table mid-round and lost their bet
+)
+
+// Won returns true if the player won the round
+func (outcome Outcome) Won() bool {
+	return outcome == PlayerBlackjack || outcome == DealerBust || outcome == PlayerWin || outcome == EvenMoney
+}
+
+// Lost returns true if the dealer won the round
+func (outcome Outcome) Lost() bool {
+	return outcome == DealerBlackjack || outcome == PlayerBust || outcome == DealerWin || outcome == Surrendered ||
+		outcome == Forfeited
+}
+
+// Result holds the final totals and credits for one of the player's hands in a settled round
+// NOTE: insurance is reported with a Result as well, only Seat, Bet and Payout are filled in
+type Result struct {
+	Seat        int // Index of the seat the hand belongs to
+	Hand        int // Index of the player's hand
+	Outcome     Outcome
+	PlayerTotal int
+	DealerTotal int
+	Bet         int // Credits the player staked
+	Payout      int // Credits handed back to the player, including the stake
+}
+
+// Net returns the credits the player won (positive) or lost (negative) on the round
+func (result Result) Net() int {
+	return result.Payout - result.Bet
+}
+
+// EventType is something that happened while a round was played
+type EventType int
+
+const (
+	HandsDealt       EventType = iota // Starting hands were dealt
+	InsuranceOffered                  // Dealer shows an ace, waiting on the players' insurance decisions
+	InsuranceSettled                  // Dealer peeked and the insurance bet is paid, see Result
+	SurrenderOffered                  // Dealer shows a ten, waiting on the players' early surrender decisions before the peek
+	DealerPeeked                      // Dealer checked the hole card and doesn't have a blackjack
+	PlayerDrew                        // Card was drawn onto player's Hand
+	PlayerSplit                       // Player split Hand into two hands
+	PlayerDoubled                     // Player doubled the bet on Hand and draws one card
+	HandBust                          // Player's Hand went over 21
+	NextHand                          // Play moved on to the seat's next Hand
+	NextSeat                          // Play moved on to the next Seat, starting with Hand
+	DealerPlays                       // Every hand is done and the dealer starts drawing
+	DealerDrew                        // Dealer drew Card
+	DealerStood                       // Dealer reached 17 without busting
+	HandSettled                       // Player's Hand is scored, see Result
+	RoundSettled                      // Every hand is settled and the round is over
+)
+
+// Event is returned by a round for every step of play so callers can report it
+type Event struct {
+	Type   EventType
+	Seat   int // Index of the seat the event is about
+	Hand   int // Index of the seat's hand the event is about
+	Card   cards.Card
+	Result Result
+}
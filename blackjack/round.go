@@ -0,0 +1,595 @@
+package blackjack
+
+import (
+	"discordgo-blackjack/cards"
+	"errors"
+)
+
+// State is the stage of play a round is in
+type State int
+
+const (
+	Betting        State = iota // Players are taking seats, waiting for the starting hands to be dealt
+	InsuranceOffer              // Dealer shows an ace, players decide on insurance or even money before the peek
+	SurrenderOffer              // Dealer shows a ten, players decide on early surrender before the peek
+	PlayerTurn                  // Seat whose turn it is acts on their hands
+	DealerTurn                  // Dealer is drawing to 17
+	Settled                     // Round is over and the results are final
+)
+
+// Action is a decision a player makes on their active hand
+type Action int
+
+const (
+	Hit Action = iota
+	Stand
+	Split
+	Double
+	Surrender
+)
+
+// DealerStandsOn the dealer stops drawing once their hand reaches this value (unless hitting soft 17)
+const DealerStandsOn = 17
+
+var (
+	// ErrWrongState is returned when a round is asked to do something its current state doesn't allow
+	ErrWrongState = errors.New("action is not allowed at this point of the round")
+
+	// ErrIllegalAction is returned when the active hand can't take the action
+	ErrIllegalAction = errors.New("action is not allowed on this hand")
+
+	// ErrNotYourTurn is returned when a seat acts while another seat is playing
+	ErrNotYourTurn = errors.New("it is another player's turn")
+
+	// ErrInsuranceTooLarge is returned when the insurance bet is more than half the stake
+	ErrInsuranceTooLarge = errors.New("insurance can be at most half of the bet")
+
+	// ErrTableFull is returned when every seat at the table is taken
+	ErrTableFull = errors.New("every seat at the table is taken")
+
+	// ErrAlreadySeated is returned when a player tries to take a second seat
+	ErrAlreadySeated = errors.New("player is already seated at the table")
+
+	// ErrNoPlayers is returned when dealing a round nobody sat down for
+	ErrNoPlayers = errors.New("nobody is seated at the table")
+)
+
+// Round holds a single round of blackjack between the seated players and the dealer
+// NOTE: Round doesn't know anything about discord, callers turn the returned events into messages
+type Round struct {
+	State      State
+	Rules      TableRules
+	Shoe       *cards.Shoe
+	Seats      []*Seat
+	Turn       int // Index of the seat that is acting
+	DealerHand []cards.Card
+}
+
+// NewRound returns a round waiting for players to sit down before it is dealt from the table's shoe
+func NewRound(shoe *cards.Shoe, rules TableRules) *Round {
+	return &Round{
+		State: Betting,
+		Rules: rules,
+		Shoe:  shoe,
+	}
+}
+
+// Sit seats a player with their stake and returns their seat index
+// NOTE: the bet is expected to already be taken from the player's wallet
+func (round *Round) Sit(playerID string, bet int) (int, error) {
+	if round.State != Betting {
+		return 0, ErrWrongState
+	}
+
+	if _, ok := round.SeatOf(playerID); ok {
+		return 0, ErrAlreadySeated
+	}
+
+	if len(round.Seats) >= round.Rules.Seats() {
+		return 0, ErrTableFull
+	}
+
+	round.Seats = append(round.Seats, &Seat{
+		PlayerID: playerID,
+		Hands:    []*Hand{{Bet: bet}},
+	})
+
+	return len(round.Seats) - 1, nil
+}
+
+// SeatOf returns the seat index of a player
+func (round *Round) SeatOf(playerID string) (int, bool) {
+	for index, seat := range round.Seats {
+		if seat.PlayerID == playerID {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+// CurrentSeat returns the seat whose turn it is
+func (round *Round) CurrentSeat() *Seat {
+	return round.Seats[round.Turn]
+}
+
+// DealerUpCard returns the dealer's face up card, the other card stays hidden until the dealer plays
+func (round *Round) DealerUpCard() cards.Card {
+	return round.DealerHand[0]
+}
+
+// CanSplit returns true if it is the seat's turn, the active hand is a pair and the table allows another hand
+// NOTE: splitting stakes the hand's bet again, callers need to take it from the player first
+func (round *Round) CanSplit(seat int) bool {
+	return round.isTurn(seat) &&
+		round.Seats[seat].ActiveHand().IsPair() &&
+		len(round.Seats[seat].Hands) < round.Rules.MaxSplitHands
+}
+
+// CanDouble returns true if it is the seat's turn and the active hand can be doubled down under the table rules
+// NOTE: doubling stakes the hand's bet again, callers need to take it from the player first
+func (round *Round) CanDouble(seat int) bool {
+	return round.isTurn(seat) && round.Rules.CanDouble(round.Seats[seat].ActiveHand())
+}
+
+// CanSurrender returns true if the seat can give up their hand on this decision under the table rules
+func (round *Round) CanSurrender(seat int) bool {
+	player := round.Seats[seat]
+	firstDecision := player.isFirstDecision() && !player.HasBlackjack()
+
+	switch round.State {
+	case InsuranceOffer, SurrenderOffer:
+		return firstDecision && !player.Decided && round.Rules.Surrender == EarlySurrender
+	case PlayerTurn:
+		return firstDecision && seat == round.Turn && round.Rules.Surrender != NoSurrender
+	}
+
+	return false
+}
+
+// Deal deals the starting hands. If the dealer shows an ace the players are offered insurance first,
+// otherwise the dealer peeks and any blackjacks are settled before play starts.
+func (round *Round) Deal() ([]Event, error) {
+	if round.State != Betting {
+		return nil, ErrWrongState
+	}
+
+	if len(round.Seats) == 0 {
+		return nil, ErrNoPlayers
+	}
+
+	playerHands, dealerHand := cards.DealStartingHands(round.Shoe, len(round.Seats))
+	for index, seat := range round.Seats {
+		seat.Hands[0].Cards = playerHands[index]
+	}
+	round.DealerHand = dealerHand
+	events := []Event{{Type: HandsDealt}}
+
+	upCard := round.DealerUpCard()
+	if upCard.IsAce() {
+		round.State = InsuranceOffer
+		return append(events, Event{Type: InsuranceOffered}), nil
+	}
+
+	// With early surrender the players get to decide before the dealer checks a ten for blackjack
+	if upCard.Rank.IsTen() && round.Rules.Surrender == EarlySurrender {
+		round.State = SurrenderOffer
+		for index, seat := range round.Seats {
+			seat.Decided = !round.CanSurrender(index)
+		}
+
+		if !round.allDecided() {
+			return append(events, Event{Type: SurrenderOffered}), nil
+		}
+	}
+
+	return append(events, round.peek()...), nil
+}
+
+// Insure places an insurance bet of up to half the seat's stake (0 declines insurance)
+// NOTE: the insurance bet is expected to already be taken from the player's wallet
+func (round *Round) Insure(seat int, amount int) ([]Event, error) {
+	player := round.Seats[seat]
+	if round.State != InsuranceOffer || player.Decided {
+		return nil, ErrWrongState
+	}
+
+	// A player with a blackjack takes even money instead
+	if amount > 0 && player.HasBlackjack() {
+		return nil, ErrIllegalAction
+	}
+
+	if amount < 0 || amount > player.MaxInsurance() {
+		return nil, ErrInsuranceTooLarge
+	}
+
+	player.Insurance = amount
+	return round.decide(seat), nil
+}
+
+// TakeEvenMoney settles a seat's blackjack against a dealer ace at 1:1 before the dealer peeks
+func (round *Round) TakeEvenMoney(seat int) ([]Event, error) {
+	player := round.Seats[seat]
+	if round.State != InsuranceOffer || player.Decided {
+		return nil, ErrWrongState
+	}
+
+	if !player.HasBlackjack() {
+		return nil, ErrIllegalAction
+	}
+
+	player.EvenMoney = true
+	player.finish()
+	return round.decide(seat), nil
+}
+
+// Decline turns down insurance, even money or early surrender for the seat
+func (round *Round) Decline(seat int) ([]Event, error) {
+	if round.State != InsuranceOffer && round.State != SurrenderOffer || round.Seats[seat].Decided {
+		return nil, ErrWrongState
+	}
+
+	return round.decide(seat), nil
+}
+
+// Apply plays the seat's action on their active hand and returns everything that happened because of it
+func (round *Round) Apply(seat int, action Action) ([]Event, error) {
+	// Early surrender can also be taken while waiting on the dealer's peek
+	if action == Surrender {
+		return round.surrender(seat)
+	}
+
+	if round.State != PlayerTurn {
+		return nil, ErrWrongState
+	}
+
+	if seat != round.Turn {
+		return nil, ErrNotYourTurn
+	}
+
+	player := round.Seats[seat]
+	hand := player.ActiveHand()
+
+	switch action {
+	case Hit:
+		events := []Event{round.drawPlayer(seat, player.Active)}
+
+		if hand.IsBust() {
+			hand.Done = true
+			events = append(events, Event{Type: HandBust, Seat: seat, Hand: player.Active})
+			events = append(events, round.advance()...)
+		}
+
+		return events, nil
+
+	case Stand:
+		hand.Done = true
+		return round.advance(), nil
+
+	case Split:
+		if !round.CanSplit(seat) {
+			return nil, ErrIllegalAction
+		}
+
+		return round.split(seat), nil
+
+	case Double:
+		if !round.CanDouble(seat) {
+			return nil, ErrIllegalAction
+		}
+
+		// Double the stake, draw exactly one card and finish the hand
+		hand.Bet *= 2
+		hand.Doubled = true
+		hand.Done = true
+		events := []Event{{Type: PlayerDoubled, Seat: seat, Hand: player.Active}, round.drawPlayer(seat, player.Active)}
+
+		if hand.IsBust() {
+			events = append(events, Event{Type: HandBust, Seat: seat, Hand: player.Active})
+		}
+
+		return append(events, round.advance()...), nil
+	}
+
+	return nil, ErrIllegalAction
+}
+
+// Leave takes the player out of the round. Before the deal the seat is freed and the caller returns the bet,
+// after the deal their bets are forfeited and play moves on if it was their turn.
+func (round *Round) Leave(seat int) ([]Event, error) {
+	switch round.State {
+	case Betting:
+		round.Seats = append(round.Seats[:seat], round.Seats[seat+1:]...)
+		return nil, nil
+	case DealerTurn, Settled:
+		return nil, ErrWrongState
+	}
+
+	player := round.Seats[seat]
+	player.Left = true
+	player.finish()
+
+	switch round.State {
+	case PlayerTurn:
+		if seat == round.Turn {
+			return round.advance(), nil
+		}
+	case InsuranceOffer, SurrenderOffer:
+		if !player.Decided {
+			return round.decide(seat), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// surrender gives up the seat's starting hand for half of the bet
+func (round *Round) surrender(seat int) ([]Event, error) {
+	if !round.CanSurrender(seat) {
+		return nil, ErrIllegalAction
+	}
+
+	player := round.Seats[seat]
+	player.Surrendered = true
+	player.finish()
+
+	if round.State == PlayerTurn {
+		return round.advance(), nil
+	}
+
+	return round.decide(seat), nil
+}
+
+// decide records the seat's decision before the peek, the dealer peeks once every seat has decided
+func (round *Round) decide(seat int) []Event {
+	round.Seats[seat].Decided = true
+
+	if !round.allDecided() {
+		return nil
+	}
+
+	return round.peek()
+}
+
+// allDecided returns true once every seat has made their decision before the peek
+func (round *Round) allDecided() bool {
+	for _, seat := range round.Seats {
+		if !seat.Decided {
+			return false
+		}
+	}
+
+	return true
+}
+
+// peek checks the dealer's hole card for a blackjack before the players act,
+// pays out any insurance and settles the round if the dealer has a blackjack
+func (round *Round) peek() []Event {
+	var events []Event
+
+	dealerBlackjack := cards.Evaluate(round.DealerHand).Blackjack
+
+	// Insurance pays 2:1 if the dealer has a blackjack
+	for index, seat := range round.Seats {
+		if seat.Insurance > 0 {
+			if dealerBlackjack {
+				seat.InsurancePaid = seat.Insurance * 3
+			}
+			events = append(events, Event{
+				Type:   InsuranceSettled,
+				Seat:   index,
+				Result: Result{Seat: index, Bet: seat.Insurance, Payout: seat.InsurancePaid},
+			})
+		}
+	}
+
+	if dealerBlackjack {
+		return append(events, round.settle()...)
+	}
+
+	// Dealer only needs to check the hole card when showing an ace or a ten
+	upCard := round.DealerUpCard()
+	if upCard.IsAce() || upCard.Rank.IsTen() {
+		events = append(events, Event{Type: DealerPeeked})
+	}
+
+	// A blackjack doesn't need to be played
+	for _, seat := range round.Seats {
+		if seat.HasBlackjack() {
+			seat.finish()
+		}
+	}
+
+	round.Turn = 0
+	return append(events, round.advance()...)
+}
+
+// split moves the second card of the seat's active pair into a new hand with the same bet
+// and deals each hand its second card. Split aces only get the one card each.
+func (round *Round) split(seat int) []Event {
+	player := round.Seats[seat]
+	hand := player.ActiveHand()
+	splitAces := hand.Cards[0].IsAce()
+
+	newHand := &Hand{
+		Cards: []cards.Card{hand.Cards[1]},
+		Bet:   hand.Bet,
+		Split: true,
+	}
+	hand.Cards = hand.Cards[:1]
+	hand.Split = true
+
+	// Keep the new hand right after the one it was split from so hands are played in order
+	next := player.Active + 1
+	player.Hands = append(player.Hands, nil)
+	copy(player.Hands[next+1:], player.Hands[next:])
+	player.Hands[next] = newHand
+
+	events := []Event{
+		{Type: PlayerSplit, Seat: seat, Hand: player.Active},
+		round.drawPlayer(seat, player.Active),
+		round.drawPlayer(seat, next),
+	}
+
+	if splitAces {
+		hand.Done = true
+		newHand.Done = true
+		events = append(events, round.advance()...)
+	}
+
+	return events
+}
+
+// drawPlayer draws a card onto one of the seat's hands
+func (round *Round) drawPlayer(seat int, hand int) Event {
+	card := round.Shoe.DrawCard()
+	round.Seats[seat].Hands[hand].Cards = append(round.Seats[seat].Hands[hand].Cards, card)
+
+	return Event{Type: PlayerDrew, Seat: seat, Hand: hand, Card: card}
+}
+
+// isTurn returns true if the seat is the one acting
+func (round *Round) isTurn(seat int) bool {
+	return round.State == PlayerTurn && seat == round.Turn
+}
+
+// advance moves play to the next unfinished hand, first in the acting seat and then in the seats after it.
+// Once every hand is done the dealer plays.
+func (round *Round) advance() []Event {
+	for index := round.Turn; index < len(round.Seats); index++ {
+		seat := round.Seats[index]
+
+		for handIndex, hand := range seat.Hands {
+			if hand.Done {
+				continue
+			}
+
+			switch {
+			case round.State != PlayerTurn || index != round.Turn:
+				round.State = PlayerTurn
+				round.Turn = index
+				seat.Active = handIndex
+				return []Event{{Type: NextSeat, Seat: index, Hand: handIndex}}
+			case handIndex != seat.Active:
+				seat.Active = handIndex
+				return []Event{{Type: NextHand, Seat: index, Hand: handIndex}}
+			}
+
+			return nil
+		}
+	}
+
+	return round.playDealer()
+}
+
+// playDealer draws for the dealer until they reach 17 or bust, then settles every hand.
+// The dealer doesn't draw if no hand is left to play against.
+func (round *Round) playDealer() []Event {
+	if !round.dealerNeedsToPlay() {
+		return round.settle()
+	}
+
+	round.State = DealerTurn
+	events := []Event{{Type: DealerPlays}}
+
+	for round.dealerShouldHit() {
+		card := round.Shoe.DrawCard()
+		round.DealerHand = append(round.DealerHand, card)
+		events = append(events, Event{Type: DealerDrew, Card: card})
+	}
+
+	if !cards.Evaluate(round.DealerHand).Bust {
+		events = append(events, Event{Type: DealerStood})
+	}
+
+	return append(events, round.settle()...)
+}
+
+// dealerNeedsToPlay returns true if any hand is still waiting on the dealer's total
+func (round *Round) dealerNeedsToPlay() bool {
+	for _, seat := range round.Seats {
+		if seat.Left || seat.Surrendered || seat.EvenMoney || seat.HasBlackjack() {
+			continue
+		}
+
+		for _, hand := range seat.Hands {
+			if !hand.IsBust() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// dealerShouldHit returns true if the dealer has to draw another card under the table rules
+func (round *Round) dealerShouldHit() bool {
+	dealer := cards.Evaluate(round.DealerHand)
+	if dealer.Total < DealerStandsOn {
+		return true
+	}
+
+	// H17 - dealer draws on soft 17 (A-6, A-3-3, A-4-2) and stands on hard 17
+	return dealer.Total == DealerStandsOn && dealer.Soft && round.Rules.DealerHitsSoft17
+}
+
+// outcome scores one of the seat's hands against the dealer
+func (round *Round) outcome(seat *Seat, hand *Hand) Outcome {
+	player := hand.Result()
+	dealer := cards.Evaluate(round.DealerHand)
+	playerBlackjack := seat.HasBlackjack()
+
+	switch {
+	case seat.Left:
+		return Forfeited
+	case seat.Surrendered:
+		return Surrendered
+	case seat.EvenMoney:
+		return EvenMoney
+	case playerBlackjack && dealer.Blackjack:
+		return Push
+	case dealer.Blackjack:
+		return DealerBlackjack
+	case playerBlackjack:
+		return PlayerBlackjack
+	case player.Bust:
+		return PlayerBust
+	case dealer.Bust:
+		return DealerBust
+	case player.Total > dealer.Total:
+		return PlayerWin
+	case player.Total < dealer.Total:
+		return DealerWin
+	}
+
+	return Push
+}
+
+// settle ends the round, scoring every hand at the table, and returns the settlement events
+func (round *Round) settle() []Event {
+	round.State = Settled
+
+	var events []Event
+	for seatIndex, seat := range round.Seats {
+		seat.finish()
+		seat.Results = nil
+
+		for handIndex, hand := range seat.Hands {
+			outcome := round.outcome(seat, hand)
+
+			result := Result{
+				Seat:        seatIndex,
+				Hand:        handIndex,
+				Outcome:     outcome,
+				PlayerTotal: hand.Value(),
+				DealerTotal: cards.HandValue(round.DealerHand),
+				Bet:         hand.Bet,
+				Payout:      round.Rules.Payout(outcome, hand.Bet),
+			}
+			seat.Results = append(seat.Results, result)
+			events = append(events, Event{Type: HandSettled, Seat: seatIndex, Hand: handIndex, Result: result})
+		}
+	}
+
+	return append(events, Event{Type: RoundSettled})
+}
@@ -0,0 +1,505 @@
+package blackjack
+
+import (
+	"discordgo-blackjack/cards"
+	"reflect"
+	"testing"
+)
+
+// stackedShoe returns a seeded shoe with the given cards on top, e.g. "TH 9S 7C 8D".
+// Starting hands are dealt one card at a time around the table, so with one player the order is
+// player, dealer up card, player, dealer hole card.
+func stackedShoe(t *testing.T, codes string) *cards.Shoe {
+	t.Helper()
+
+	top, err := cards.DecodeCards(codes)
+	if err != nil {
+		t.Fatalf("DecodeCards(%q): %v", codes, err)
+	}
+
+	shoe := cards.NewShoe(1, DefaultPenetration, cards.NewSeededSource(1))
+	shoe.Deck.Cards = append(top, shoe.Deck.Cards...)
+
+	return shoe
+}
+
+// newTestRound deals a round with one seat per bet from a stacked shoe
+func newTestRound(t *testing.T, rules TableRules, codes string, bets ...int) (*Round, []Event) {
+	t.Helper()
+
+	round := NewRound(stackedShoe(t, codes), rules)
+	for index, bet := range bets {
+		if _, err := round.Sit(string(rune('a'+index)), bet); err != nil {
+			t.Fatalf("Sit: %v", err)
+		}
+	}
+
+	events, err := round.Deal()
+	if err != nil {
+		t.Fatalf("Deal: %v", err)
+	}
+
+	return round, events
+}
+
+// apply plays the action and fails the test if it isn't accepted
+func apply(t *testing.T, round *Round, seat int, action Action) []Event {
+	t.Helper()
+
+	events, err := round.Apply(seat, action)
+	if err != nil {
+		t.Fatalf("Apply(%d, %v): %v", seat, action, err)
+	}
+
+	return events
+}
+
+// checkState fails the test if the round isn't in the expected state
+func checkState(t *testing.T, round *Round, want State) {
+	t.Helper()
+
+	if round.State != want {
+		t.Fatalf("round is in state %d, want %d", round.State, want)
+	}
+}
+
+// checkResult fails the test if the hand didn't settle with the outcome and payout
+func checkResult(t *testing.T, round *Round, seat int, hand int, outcome Outcome, payout int) {
+	t.Helper()
+
+	checkState(t, round, Settled)
+	result := round.Seats[seat].Results[hand]
+	if result.Outcome != outcome || result.Payout != payout {
+		t.Fatalf("seat %d hand %d settled as outcome %d paying %d, want outcome %d paying %d",
+			seat, hand, result.Outcome, result.Payout, outcome, payout)
+	}
+}
+
+// hasEvent returns true if an event of the type is in the list
+func hasEvent(events []Event, eventType EventType) bool {
+	for _, event := range events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStandAgainstDealer(t *testing.T) {
+	round, events := newTestRound(t, DefaultTableRules(), "TH 9S 7C 8D", 10)
+	checkState(t, round, PlayerTurn)
+	if hasEvent(events, DealerPeeked) {
+		t.Fatal("dealer peeked while showing a nine")
+	}
+
+	events = apply(t, round, 0, Stand)
+	if !hasEvent(events, DealerPlays) || !hasEvent(events, DealerStood) || !hasEvent(events, RoundSettled) {
+		t.Fatalf("standing didn't play out the dealer: %+v", events)
+	}
+	checkResult(t, round, 0, 0, Push, 10)
+}
+
+func TestHitToWin(t *testing.T) {
+	round, _ := newTestRound(t, DefaultTableRules(), "TH 9S 6C 8D 4H", 10)
+
+	events := apply(t, round, 0, Hit)
+	if len(events) != 1 || events[0].Type != PlayerDrew || events[0].Card.Code() != "4H" {
+		t.Fatalf("hit events = %+v, want the 4H drawn", events)
+	}
+	checkState(t, round, PlayerTurn)
+
+	apply(t, round, 0, Stand)
+	checkResult(t, round, 0, 0, PlayerWin, 20)
+}
+
+func TestHitToBust(t *testing.T) {
+	round, _ := newTestRound(t, DefaultTableRules(), "TH 9S 6C 8D KH", 10)
+
+	events := apply(t, round, 0, Hit)
+	if !hasEvent(events, HandBust) {
+		t.Fatalf("hit events = %+v, want a bust", events)
+	}
+
+	// Nothing left to play against, so the dealer doesn't draw
+	if hasEvent(events, DealerPlays) {
+		t.Fatal("dealer played against a bust hand")
+	}
+	checkResult(t, round, 0, 0, PlayerBust, 0)
+}
+
+func TestActionsOutOfTurn(t *testing.T) {
+	round := NewRound(stackedShoe(t, ""), DefaultTableRules())
+	if _, err := round.Deal(); err != ErrNoPlayers {
+		t.Fatalf("Deal with no players returned %v, want ErrNoPlayers", err)
+	}
+
+	for index := 0; index < MaxSeats; index++ {
+		if _, err := round.Sit(string(rune('a'+index)), 10); err != nil {
+			t.Fatalf("Sit: %v", err)
+		}
+	}
+	if _, err := round.Sit("a", 10); err != ErrAlreadySeated {
+		t.Fatalf("sitting twice returned %v, want ErrAlreadySeated", err)
+	}
+	if _, err := round.Sit("z", 10); err != ErrTableFull {
+		t.Fatalf("sitting at a full table returned %v, want ErrTableFull", err)
+	}
+	if _, err := round.Apply(0, Hit); err != ErrWrongState {
+		t.Fatalf("hitting before the deal returned %v, want ErrWrongState", err)
+	}
+
+	// Nine up, no peek: play starts with the first seat
+	round, _ = newTestRound(t, DefaultTableRules(), "TH 9C 9S 7C 5C 8D", 10, 10)
+	if _, err := round.Apply(1, Stand); err != ErrNotYourTurn {
+		t.Fatalf("second seat acting first returned %v, want ErrNotYourTurn", err)
+	}
+
+	events := apply(t, round, 0, Stand)
+	if len(events) != 1 || events[0].Type != NextSeat || events[0].Seat != 1 {
+		t.Fatalf("standing on the first seat = %+v, want play to move to seat 1", events)
+	}
+	if _, err := round.Apply(0, Hit); err != ErrNotYourTurn {
+		t.Fatalf("first seat acting again returned %v, want ErrNotYourTurn", err)
+	}
+}
+
+func TestInsuranceAgainstDealerBlackjack(t *testing.T) {
+	round, events := newTestRound(t, DefaultTableRules(), "TH AS 9C KD", 10)
+	checkState(t, round, InsuranceOffer)
+	if !hasEvent(events, InsuranceOffered) {
+		t.Fatalf("deal events = %+v, want insurance offered", events)
+	}
+
+	if _, err := round.Insure(0, 6); err != ErrInsuranceTooLarge {
+		t.Fatalf("insuring more than half the bet returned %v, want ErrInsuranceTooLarge", err)
+	}
+	if _, err := round.Insure(0, 5); err != nil {
+		t.Fatalf("Insure: %v", err)
+	}
+
+	// Insurance pays 2:1 and the hand loses to the dealer's blackjack, the player breaks even
+	checkResult(t, round, 0, 0, DealerBlackjack, 0)
+	if paid := round.Seats[0].InsurancePaid; paid != 15 {
+		t.Fatalf("insurance paid %d, want 15", paid)
+	}
+	if total := round.Seats[0].TotalPayout(); total != 15 {
+		t.Fatalf("total payout %d, want 15", total)
+	}
+}
+
+func TestInsuranceLostThenPlay(t *testing.T) {
+	round, _ := newTestRound(t, DefaultTableRules(), "TH AS 9C 7D", 10)
+
+	events, err := round.Insure(0, 5)
+	if err != nil {
+		t.Fatalf("Insure: %v", err)
+	}
+	if !hasEvent(events, InsuranceSettled) || !hasEvent(events, DealerPeeked) {
+		t.Fatalf("insure events = %+v, want insurance settled and the peek", events)
+	}
+	checkState(t, round, PlayerTurn)
+
+	// 19 against a soft 18, the dealer stands on every 17 or more
+	apply(t, round, 0, Stand)
+	checkResult(t, round, 0, 0, PlayerWin, 20)
+	if paid := round.Seats[0].InsurancePaid; paid != 0 {
+		t.Fatalf("lost insurance paid %d", paid)
+	}
+}
+
+func TestEvenMoney(t *testing.T) {
+	round, _ := newTestRound(t, DefaultTableRules(), "AH AS KC 7D", 10)
+
+	if _, err := round.Insure(0, 5); err != ErrIllegalAction {
+		t.Fatalf("insuring a blackjack returned %v, want ErrIllegalAction", err)
+	}
+	if _, err := round.TakeEvenMoney(0); err != nil {
+		t.Fatalf("TakeEvenMoney: %v", err)
+	}
+	checkResult(t, round, 0, 0, EvenMoney, 20)
+}
+
+func TestEarlySurrender(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.Surrender = EarlySurrender
+
+	round, events := newTestRound(t, rules, "TH KS 6C 9D", 10)
+	checkState(t, round, SurrenderOffer)
+	if !hasEvent(events, SurrenderOffered) {
+		t.Fatalf("deal events = %+v, want surrender offered", events)
+	}
+
+	apply(t, round, 0, Surrender)
+	checkResult(t, round, 0, 0, Surrendered, 5)
+}
+
+func TestDeclineEarlySurrender(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.Surrender = EarlySurrender
+
+	round, _ := newTestRound(t, rules, "TH KS 6C 9D", 10)
+	if _, err := round.Decline(0); err != nil {
+		t.Fatalf("Decline: %v", err)
+	}
+	checkState(t, round, PlayerTurn)
+
+	// The offer is over, late surrender is still allowed on the first decision
+	apply(t, round, 0, Surrender)
+	checkResult(t, round, 0, 0, Surrendered, 5)
+}
+
+func TestNoSurrender(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.Surrender = NoSurrender
+
+	round, _ := newTestRound(t, rules, "TH 9S 6C 8D", 10)
+	if _, err := round.Apply(0, Surrender); err != ErrIllegalAction {
+		t.Fatalf("surrendering returned %v, want ErrIllegalAction", err)
+	}
+}
+
+func TestSplitAndDoubleAfterSplit(t *testing.T) {
+	// Pair of eights against a 16, the dealer busts drawing a ten
+	round, _ := newTestRound(t, DefaultTableRules(), "8H 9S 8C 7D 3H TC 9D TS", 10)
+	if !round.CanSplit(0) {
+		t.Fatal("can't split a pair of eights")
+	}
+
+	events := apply(t, round, 0, Split)
+	if !hasEvent(events, PlayerSplit) {
+		t.Fatalf("split events = %+v", events)
+	}
+	seat := round.Seats[0]
+	if len(seat.Hands) != 2 || cards.EncodeCards(seat.Hands[0].Cards) != "8H 3H" ||
+		cards.EncodeCards(seat.Hands[1].Cards) != "8C TC" {
+		t.Fatalf("split hands = %+v", seat.Hands)
+	}
+
+	// 11 on the first hand, doubled for one card
+	events = apply(t, round, 0, Double)
+	if !hasEvent(events, NextHand) {
+		t.Fatalf("double events = %+v, want play to move to the second hand", events)
+	}
+	if hand := seat.Hands[0]; !hand.Doubled || hand.Bet != 20 || hand.Value() != 20 {
+		t.Fatalf("doubled hand = %+v", hand)
+	}
+
+	apply(t, round, 0, Stand)
+	checkResult(t, round, 0, 0, DealerBust, 40)
+	checkResult(t, round, 0, 1, DealerBust, 20)
+	if total := seat.TotalBet(); total != 30 {
+		t.Fatalf("total bet %d, want 30", total)
+	}
+}
+
+func TestNoDoubleAfterSplit(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.DoubleAfterSplit = false
+
+	round, _ := newTestRound(t, rules, "8H 9S 8C 7D 3H TC", 10)
+	apply(t, round, 0, Split)
+	if _, err := round.Apply(0, Double); err != ErrIllegalAction {
+		t.Fatalf("doubling a split hand returned %v, want ErrIllegalAction", err)
+	}
+}
+
+func TestDoubleNineToEleven(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.DoubleOn = DoubleNineToEleven
+
+	round, _ := newTestRound(t, rules, "TH 9S 7C 8D", 10)
+	if _, err := round.Apply(0, Double); err != ErrIllegalAction {
+		t.Fatalf("doubling 17 returned %v, want ErrIllegalAction", err)
+	}
+
+	round, _ = newTestRound(t, rules, "6H 9S 4C 8D TD", 10)
+	apply(t, round, 0, Double)
+	checkResult(t, round, 0, 0, PlayerWin, 40)
+}
+
+func TestSplitAcesAreNotBlackjacks(t *testing.T) {
+	rules := DefaultTableRules()
+	round, _ := newTestRound(t, rules, "AH 9S AC 7D KH KC 2S", 10)
+
+	// Split aces get one card each and play moves straight on to the dealer
+	events := apply(t, round, 0, Split)
+	if !hasEvent(events, DealerPlays) {
+		t.Fatalf("split aces events = %+v, want the dealer to play", events)
+	}
+
+	// 21 on each hand pays even money, not the blackjack payout
+	checkResult(t, round, 0, 0, PlayerWin, 20)
+	checkResult(t, round, 0, 1, PlayerWin, 20)
+}
+
+func TestResplitLimit(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.MaxSplitHands = 2
+
+	round, _ := newTestRound(t, rules, "8H 9S 8C 7D 8D TC", 10)
+	apply(t, round, 0, Split)
+	if round.CanSplit(0) {
+		t.Fatal("can split past the table's hand limit")
+	}
+	if _, err := round.Apply(0, Split); err != ErrIllegalAction {
+		t.Fatalf("re-splitting returned %v, want ErrIllegalAction", err)
+	}
+}
+
+func TestDealerSoft17(t *testing.T) {
+	// 19 against a dealer Ace-6, the next card is a two
+	const shoe = "TH AS 9C 6D 2H"
+
+	rules := DefaultTableRules()
+	round, _ := newTestRound(t, rules, shoe, 10)
+	if _, err := round.Decline(0); err != nil {
+		t.Fatalf("Decline: %v", err)
+	}
+	apply(t, round, 0, Stand)
+	checkResult(t, round, 0, 0, PlayerWin, 20)
+	if len(round.DealerHand) != 2 {
+		t.Fatalf("S17 dealer drew on soft 17: %s", cards.EncodeCards(round.DealerHand))
+	}
+
+	rules.DealerHitsSoft17 = true
+	round, _ = newTestRound(t, rules, shoe, 10)
+	if _, err := round.Decline(0); err != nil {
+		t.Fatalf("Decline: %v", err)
+	}
+	apply(t, round, 0, Stand)
+	checkResult(t, round, 0, 0, Push, 10)
+	if len(round.DealerHand) != 3 {
+		t.Fatalf("H17 dealer stood on soft 17: %s", cards.EncodeCards(round.DealerHand))
+	}
+}
+
+func TestPlayerBlackjackPayout(t *testing.T) {
+	tests := []struct {
+		pays   BlackjackPayout
+		bet    int
+		payout int
+	}{
+		{ThreeToTwo, 10, 25},
+		{ThreeToTwo, 15, 37}, // 22.5 rounds down
+		{SixToFive, 10, 22},  // 12 rounds down
+		{SixToFive, 15, 33},
+		{SixToFive, 25, 55},
+	}
+
+	for _, test := range tests {
+		rules := DefaultTableRules()
+		rules.BlackjackPays = test.pays
+
+		// A blackjack against a nine is settled on the deal
+		round, _ := newTestRound(t, rules, "AH 9S KC 7D", test.bet)
+		checkResult(t, round, 0, 0, PlayerBlackjack, test.payout)
+	}
+}
+
+func TestPayouts(t *testing.T) {
+	rules := DefaultTableRules()
+	tests := map[Outcome]int{
+		PlayerBlackjack: 25,
+		DealerBust:      20,
+		PlayerWin:       20,
+		EvenMoney:       20,
+		Push:            10,
+		Surrendered:     5,
+		DealerBlackjack: 0,
+		PlayerBust:      0,
+		DealerWin:       0,
+		Forfeited:       0,
+	}
+
+	for outcome, want := range tests {
+		if payout := rules.Payout(outcome, 10); payout != want {
+			t.Errorf("Payout(%d, 10) = %d, want %d", outcome, payout, want)
+		}
+	}
+}
+
+func TestBothBlackjacksPush(t *testing.T) {
+	round, _ := newTestRound(t, DefaultTableRules(), "AH KS KC AD", 10)
+	if _, err := round.Apply(0, Surrender); err != ErrIllegalAction {
+		t.Fatalf("surrendering a blackjack returned %v, want ErrIllegalAction", err)
+	}
+	checkResult(t, round, 0, 0, Push, 10)
+}
+
+func TestLeaveForfeitsBets(t *testing.T) {
+	// Seat 1 stands on 19 against the dealer's 17
+	round, _ := newTestRound(t, DefaultTableRules(), "TH 9C 9S 7C QC 8D", 10, 10)
+
+	events, err := round.Leave(0)
+	if err != nil {
+		t.Fatalf("Leave: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != NextSeat {
+		t.Fatalf("leave events = %+v, want play to move to the next seat", events)
+	}
+
+	apply(t, round, 1, Stand)
+	checkResult(t, round, 0, 0, Forfeited, 0)
+	checkResult(t, round, 1, 0, PlayerWin, 20)
+}
+
+// playSeededRound plays a round from a seeded shoe, every seat hitting below 17
+func playSeededRound(t *testing.T, seed int64) []Event {
+	round := NewRound(cards.NewShoe(6, DefaultPenetration, cards.NewSeededSource(seed)), DefaultTableRules())
+	for _, player := range []string{"a", "b", "c"} {
+		if _, err := round.Sit(player, 10); err != nil {
+			t.Fatalf("Sit: %v", err)
+		}
+	}
+
+	events, err := round.Deal()
+	if err != nil {
+		t.Fatalf("Deal: %v", err)
+	}
+
+	for round.State != Settled {
+		var more []Event
+		switch round.State {
+		case InsuranceOffer, SurrenderOffer:
+			for index, seat := range round.Seats {
+				if !seat.Decided {
+					more, err = round.Decline(index)
+					break
+				}
+			}
+		case PlayerTurn:
+			action := Stand
+			if round.CurrentSeat().ActiveHand().Value() < DealerStandsOn {
+				action = Hit
+			}
+			more, err = round.Apply(round.Turn, action)
+		default:
+			t.Fatalf("round stopped in state %d", round.State)
+		}
+		if err != nil {
+			t.Fatalf("playing the round: %v", err)
+		}
+		events = append(events, more...)
+	}
+
+	for index, seat := range round.Seats {
+		if len(seat.Results) != len(seat.Hands) {
+			t.Fatalf("seat %d has %d results for %d hands", index, len(seat.Results), len(seat.Hands))
+		}
+	}
+
+	return events
+}
+
+func TestSeededRoundsReplay(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		first := playSeededRound(t, seed)
+		if !reflect.DeepEqual(first, playSeededRound(t, seed)) {
+			t.Fatalf("seed %d played out differently the second time", seed)
+		}
+		if first[len(first)-1].Type != RoundSettled {
+			t.Fatalf("seed %d didn't end with the round settled", seed)
+		}
+	}
+}
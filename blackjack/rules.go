@@ -0,0 +1,226 @@
+package blackjack
+
+import (
+	"discordgo-blackjack/cards"
+	"errors"
+	"sort"
+)
+
+// Default table rules
+const (
+	DefaultMinBet        = 10
+	DefaultMaxBet        = 5000
+	DefaultMaxSplitHands = 4
+	DefaultDecks         = 6
+	DefaultPenetration   = 75
+)
+
+// Limits on the shoe a table can be configured with
+const (
+	MinDecks       = 1
+	MaxDecks       = 8
+	MinPenetration = 50
+	MaxPenetration = 90
+)
+
+// BlackjackPayout is what a natural blackjack pays
+type BlackjackPayout int
+
+const (
+	ThreeToTwo BlackjackPayout = iota // 3:2
+	SixToFive                         // 6:5
+)
+
+// DoubleRule decides which starting hands a player can double down on
+type DoubleRule int
+
+const (
+	DoubleAnyTwo       DoubleRule = iota // Double on any first two cards
+	DoubleNineToEleven                   // Double only on a first two cards totalling 9, 10 or 11
+)
+
+// SurrenderRule decides if and when a player can give up half their bet instead of playing the hand
+type SurrenderRule int
+
+const (
+	NoSurrender    SurrenderRule = iota // Surrender isn't offered
+	LateSurrender                       // Surrender on the first decision, after the dealer peeks for blackjack
+	EarlySurrender                      // Surrender on the first decision, even before the dealer peeks
+)
+
+// Errors returned when a bet can't be placed
+var (
+	ErrBetTooSmall         = errors.New("bet is below the table minimum")
+	ErrBetTooLarge         = errors.New("bet is above the table maximum")
+	ErrInsufficientCredits = errors.New("not enough credits to cover the bet")
+	ErrInvalidLimits       = errors.New("table minimum must be positive and no larger than the maximum")
+	ErrInvalidSplitLimit   = errors.New("a player must be allowed at least one hand")
+	ErrInvalidDecks        = errors.New("a shoe must have between 1 and 8 decks")
+	ErrInvalidPenetration  = errors.New("penetration must be between 50% and 90% of the shoe")
+	ErrShoeTooSmall        = errors.New("the shoe can't cover a round for one player, add decks or allow fewer split hands")
+)
+
+// TableRules holds the house rules a table is played with
+type TableRules struct {
+	MinBet int // Smallest stake a player can bet on a round
+	MaxBet int // Largest stake a player can bet on a round
+
+	Decks       int // Number of decks in the shoe
+	Penetration int // Percent of the shoe dealt before it is reshuffled
+
+	DealerHitsSoft17 bool            // Dealer draws on a soft 17 instead of standing on every 17
+	BlackjackPays    BlackjackPayout // What a natural blackjack pays
+
+	MaxSplitHands int // Most hands a player can end up with by splitting and re-splitting (1 turns splitting off)
+
+	DoubleOn         DoubleRule // Which starting hands can be doubled
+	DoubleAfterSplit bool       // Hands created by a split can be doubled
+
+	Surrender SurrenderRule // When the player can surrender
+}
+
+// DefaultTableRules returns the rules used by a guild that hasn't configured its own
+func DefaultTableRules() TableRules {
+	return TableRules{
+		MinBet: DefaultMinBet,
+		MaxBet: DefaultMaxBet,
+
+		Decks:       DefaultDecks,
+		Penetration: DefaultPenetration,
+
+		DealerHitsSoft17: false,
+		BlackjackPays:    ThreeToTwo,
+
+		MaxSplitHands: DefaultMaxSplitHands,
+
+		DoubleOn:         DoubleAnyTwo,
+		DoubleAfterSplit: true,
+
+		Surrender: LateSurrender,
+	}
+}
+
+// Validate checks that the rules can be played with
+func (rules TableRules) Validate() error {
+	if rules.MinBet <= 0 || rules.MinBet > rules.MaxBet {
+		return ErrInvalidLimits
+	}
+
+	if rules.Decks < MinDecks || rules.Decks > MaxDecks {
+		return ErrInvalidDecks
+	}
+
+	if rules.Penetration < MinPenetration || rules.Penetration > MaxPenetration {
+		return ErrInvalidPenetration
+	}
+
+	if rules.MaxSplitHands < 1 {
+		return ErrInvalidSplitLimit
+	}
+
+	if rules.Seats() == 0 {
+		return ErrShoeTooSmall
+	}
+
+	return nil
+}
+
+// MaxRoundCards returns the most cards a round with the given number of seats could draw from the remaining cards.
+// Before its last card a hand totals at most 21 counting every ace as 1 (the dealer's at most 16), so a round draws
+// no more than the smallest remaining cards that fit under those totals, plus one last card for every hand.
+func (rules TableRules) MaxRoundCards(seats int, remaining []cards.Card) int {
+	hands := seats * rules.MaxSplitHands
+	budget := 21*hands + DealerStandsOn - 1
+
+	values := make([]int, len(remaining))
+	for index, card := range remaining {
+		values[index] = card.Value()
+		if card.IsAce() {
+			values[index] = 1
+		}
+	}
+	sort.Ints(values)
+
+	drawn := 0
+	for _, value := range values {
+		if budget < value {
+			break
+		}
+		budget -= value
+		drawn++
+	}
+
+	return drawn + hands + 1
+}
+
+// ShoeCovers returns true if the shoe has enough cards left to finish a round with the given number of seats
+// however it is played. The shoe is never reshuffled mid-round, so it has to be reshuffled before a round it can't cover.
+func (rules TableRules) ShoeCovers(shoe *cards.Shoe, seats int) bool {
+	return rules.MaxRoundCards(seats, shoe.Deck.Cards) <= shoe.CardsRemaining()
+}
+
+// Seats returns how many players can sit at a table, at most MaxSeats. A small shoe with many split hands
+// allowed offers fewer seats, so a freshly shuffled shoe can always cover a round.
+func (rules TableRules) Seats() int {
+	var deck cards.Deck
+	deck.CreateDeck(rules.Decks)
+
+	seats := 0
+	for seats < MaxSeats && rules.MaxRoundCards(seats+1, deck.Cards) <= len(deck.Cards) {
+		seats++
+	}
+
+	return seats
+}
+
+// Payout returns the credits handed back to the player for the outcome, including their stake
+// (1:1 on a normal win, 3:2 or 6:5 on a blackjack, stake returned on a push, half the stake on a surrender)
+func (rules TableRules) Payout(outcome Outcome, bet int) int {
+	switch outcome {
+	case PlayerBlackjack:
+		if rules.BlackjackPays == SixToFive {
+			return bet + bet*6/5
+		}
+		return bet + bet*3/2
+	case DealerBust, PlayerWin, EvenMoney:
+		return bet * 2
+	case Push:
+		return bet
+	case Surrendered:
+		return bet / 2
+	}
+
+	return 0
+}
+
+// CanDouble returns true if the rules allow doubling down on the hand
+func (rules TableRules) CanDouble(hand *Hand) bool {
+	if len(hand.Cards) != 2 {
+		return false
+	}
+
+	if hand.Split && !rules.DoubleAfterSplit {
+		return false
+	}
+
+	if rules.DoubleOn == DoubleNineToEleven {
+		value := hand.Value()
+		return value >= 9 && value <= 11
+	}
+
+	return true
+}
+
+// CheckBet checks that a player with the given credits can bet the amount at this table
+func (rules TableRules) CheckBet(bet int, credits int) error {
+	switch {
+	case bet < rules.MinBet:
+		return ErrBetTooSmall
+	case bet > rules.MaxBet:
+		return ErrBetTooLarge
+	case bet > credits:
+		return ErrInsufficientCredits
+	}
+
+	return nil
+}
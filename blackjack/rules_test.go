@@ -0,0 +1,137 @@
+package blackjack
+
+import (
+	"discordgo-blackjack/cards"
+	"testing"
+)
+
+func TestSeatsCoveredByAFreshShoe(t *testing.T) {
+	tests := []struct {
+		decks      int
+		splitHands int
+		seats      int
+	}{
+		{1, 1, 7},
+		{1, 4, 2},
+		{2, 4, 5},
+		{6, 4, 7},
+		{8, 4, 7},
+		{1, 20, 0},
+	}
+
+	for _, test := range tests {
+		rules := DefaultTableRules()
+		rules.Decks = test.decks
+		rules.MaxSplitHands = test.splitHands
+
+		if seats := rules.Seats(); seats != test.seats {
+			t.Errorf("%d decks with %d split hands seats %d players, want %d",
+				test.decks, test.splitHands, seats, test.seats)
+		}
+	}
+}
+
+func TestValidateShoeTooSmall(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.Decks = 1
+	rules.MaxSplitHands = 20
+	if err := rules.Validate(); err != ErrShoeTooSmall {
+		t.Fatalf("Validate returned %v, want ErrShoeTooSmall", err)
+	}
+
+	rules.MaxSplitHands = 1
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate returned %v for a single deck without splits", err)
+	}
+}
+
+func TestMaxRoundCards(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.MaxSplitHands = 1
+
+	// One hand: every ace, two and three (24 points) and three fours, 12 more under the 37 point budget
+	var deck cards.Deck
+	deck.CreateDeck(1)
+	if needed := rules.MaxRoundCards(1, deck.Cards); needed != 15+2 {
+		t.Fatalf("MaxRoundCards(1) = %d, want 17", needed)
+	}
+
+	// Tens only: a hand draws at most two before busting, the dealer two
+	tens, _ := cards.DecodeCards("TS TH TD TC JS JH JD JC QS QH")
+	if needed := rules.MaxRoundCards(1, tens); needed != 5 {
+		t.Fatalf("MaxRoundCards(1) over tens = %d, want 5", needed)
+	}
+}
+
+// TestShoeNeverRunsDry plays many rounds at the smallest shoe and deepest penetration the rules allow,
+// with every seat taken and every pair split. A round that ran the shoe dry would panic in DrawCard.
+func TestShoeNeverRunsDry(t *testing.T) {
+	rules := DefaultTableRules()
+	rules.Decks = MinDecks
+	rules.Penetration = MaxPenetration
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	shoe := cards.NewShoe(rules.Decks, rules.Penetration, cards.NewSeededSource(5))
+	for roundNumber := 0; roundNumber < 2000; roundNumber++ {
+		seats := rules.Seats()
+		if shoe.NeedsShuffle() || !rules.ShoeCovers(shoe, seats) {
+			shoe.Shuffle()
+		}
+
+		round := NewRound(shoe, rules)
+		for seat := 0; seat < seats; seat++ {
+			if _, err := round.Sit(string(rune('a'+seat)), 10); err != nil {
+				t.Fatalf("Sit: %v", err)
+			}
+		}
+		if _, err := round.Sit("extra", 10); err != ErrTableFull {
+			t.Fatalf("sitting past the shoe's seats returned %v, want ErrTableFull", err)
+		}
+
+		if _, err := round.Deal(); err != nil {
+			t.Fatalf("Deal: %v", err)
+		}
+
+		for round.State != Settled {
+			var err error
+			switch round.State {
+			case InsuranceOffer, SurrenderOffer:
+				for index, seat := range round.Seats {
+					if !seat.Decided {
+						_, err = round.Decline(index)
+						break
+					}
+				}
+			case PlayerTurn:
+				switch {
+				case round.CanSplit(round.Turn):
+					_, err = round.Apply(round.Turn, Split)
+				case round.CurrentSeat().ActiveHand().Value() < 21:
+					_, err = round.Apply(round.Turn, Hit)
+				default:
+					_, err = round.Apply(round.Turn, Stand)
+				}
+			}
+			if err != nil {
+				t.Fatalf("round %d: %v", roundNumber, err)
+			}
+		}
+
+		// A single deck shoe that was reshuffled mid-round would put cards on the table twice
+		seen := make(map[cards.Card]bool)
+		tableCards := append([]cards.Card{}, round.DealerHand...)
+		for _, seat := range round.Seats {
+			for _, hand := range seat.Hands {
+				tableCards = append(tableCards, hand.Cards...)
+			}
+		}
+		for _, card := range tableCards {
+			if seen[card] {
+				t.Fatalf("round %d dealt %s twice", roundNumber, card.Code())
+			}
+			seen[card] = true
+		}
+	}
+}
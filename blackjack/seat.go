@@ -0,0 +1,68 @@
+package blackjack
+
+// MaxSeats is the most players that can sit at a table
+const MaxSeats = 7
+
+// Seat is a player sitting at the table with their hands and side bets
+type Seat struct {
+	PlayerID string  // Identifies the player to the caller, the round doesn't use it
+	Hands    []*Hand // Player's hands, more than one after a split
+	Active   int     // Index of the hand the player is acting on
+
+	Insurance     int // Side bet against a dealer blackjack, already taken from the player's wallet
+	InsurancePaid int // Credits handed back for the insurance bet once the dealer has peeked
+
+	Decided     bool // Player has made their decision before the dealer's peek
+	EvenMoney   bool // Player took even money on their blackjack
+	Surrendered bool // Player gave up their hand for half the bet
+	Left        bool // Player left the table mid-round and forfeits their bets
+
+	Results []Result // One per hand, only filled in once the round is settled
+}
+
+// ActiveHand returns the hand the player is acting on
+func (seat *Seat) ActiveHand() *Hand {
+	return seat.Hands[seat.Active]
+}
+
+// TotalBet returns the credits staked across all of the player's hands
+func (seat *Seat) TotalBet() int {
+	total := 0
+	for _, hand := range seat.Hands {
+		total += hand.Bet
+	}
+
+	return total
+}
+
+// TotalPayout returns the credits handed back to the player for the round, including insurance
+func (seat *Seat) TotalPayout() int {
+	total := seat.InsurancePaid
+	for _, result := range seat.Results {
+		total += result.Payout
+	}
+
+	return total
+}
+
+// HasBlackjack returns true if the player's starting hand is a blackjack
+func (seat *Seat) HasBlackjack() bool {
+	return len(seat.Hands) == 1 && seat.Hands[0].Result().Blackjack
+}
+
+// MaxInsurance returns the largest insurance bet the player can place
+func (seat *Seat) MaxInsurance() int {
+	return seat.Hands[0].Bet / 2
+}
+
+// isFirstDecision returns true if the player hasn't acted on their starting hand yet
+func (seat *Seat) isFirstDecision() bool {
+	return len(seat.Hands) == 1 && len(seat.Hands[0].Cards) == 2 && !seat.Hands[0].Done
+}
+
+// finish marks every hand of the seat as done
+func (seat *Seat) finish() {
+	for _, hand := range seat.Hands {
+		hand.Done = true
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"discordgo-blackjack/blackjack"
+	"discordgo-blackjack/cards"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Custom ids of the buttons on the table embed
+const (
+	ButtonHit       = "blackjack_hit"
+	ButtonStand     = "blackjack_stand"
+	ButtonDouble    = "blackjack_double"
+	ButtonSplit     = "blackjack_split"
+	ButtonSurrender = "blackjack_surrender"
+	ButtonInsurance = "blackjack_insurance"
+	ButtonEvenMoney = "blackjack_even_money"
+	ButtonDecline   = "blackjack_decline"
+	ButtonLeave     = "blackjack_leave"
+)
+
+// TableButtons Returns the action buttons for the table embed. A button is only enabled while
+// some seat can legally make that move, so a settled round has every button greyed out.
+func TableButtons(round *blackjack.Round) []discordgo.MessageComponent {
+	playing := round.State == blackjack.PlayerTurn
+	offer := round.State == blackjack.InsuranceOffer || round.State == blackjack.SurrenderOffer
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				tableButton("Hit", ButtonHit, cards.TAP_HIT, discordgo.PrimaryButton, playing),
+				tableButton("Stand", ButtonStand, cards.TAP_STAND, discordgo.SecondaryButton, playing),
+				tableButton("Double", ButtonDouble, cards.DOUBLE_DOWN, discordgo.SuccessButton,
+					playing && round.CanDouble(round.Turn)),
+				tableButton("Split", ButtonSplit, cards.SPLIT_HAND, discordgo.SuccessButton,
+					playing && round.CanSplit(round.Turn)),
+				tableButton("Surrender", ButtonSurrender, cards.SURRENDER, discordgo.DangerButton,
+					anySeat(round, round.CanSurrender)),
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				tableButton("Insurance", ButtonInsurance, cards.INSURANCE, discordgo.SecondaryButton,
+					round.State == blackjack.InsuranceOffer && anySeat(round, func(seat int) bool {
+						return !round.Seats[seat].Decided && !round.Seats[seat].HasBlackjack()
+					})),
+				tableButton("Even Money", ButtonEvenMoney, cards.EVEN_MONEY, discordgo.SecondaryButton,
+					round.State == blackjack.InsuranceOffer && anySeat(round, func(seat int) bool {
+						return !round.Seats[seat].Decided && round.Seats[seat].HasBlackjack()
+					})),
+				tableButton("Decline", ButtonDecline, cards.CHECKBOX_DECLINE, discordgo.SecondaryButton,
+					offer && anySeat(round, func(seat int) bool {
+						return !round.Seats[seat].Decided
+					})),
+				tableButton("Leave", ButtonLeave, cards.STOP_SIGN_EMOJI, discordgo.DangerButton,
+					round.State != blackjack.Settled),
+			},
+		},
+	}
+}
+
+// tableButton Returns one of the table's buttons
+func tableButton(label string, customID string, emoji string, style discordgo.ButtonStyle, enabled bool) discordgo.Button {
+	return discordgo.Button{
+		Label:    label,
+		Style:    style,
+		Disabled: !enabled,
+		Emoji:    discordgo.ComponentEmoji{Name: emoji},
+		CustomID: customID,
+	}
+}
+
+// anySeat Returns true if the check passes for at least one seat at the table
+func anySeat(round *blackjack.Round, check func(seat int) bool) bool {
+	for seat := range round.Seats {
+		if check(seat) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ButtonHandler Handles a player pressing one of the buttons on a table embed
+func ButtonHandler(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+
+	// Only buttons on a table embed are handled
+	table, ok := Tables.ByMessage(interaction.Message.ID)
+	if !ok {
+		RespondPrivate(session, interaction.Interaction, "This table is no longer being played.")
+		return
+	}
+
+	// Acknowledge the press right away, Discord only waits 3 seconds and a move can wait on the table lock
+	// and save to the database. The table embed is edited in place once the move is played.
+	err := session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		fmt.Println("Error answering button press:", err)
+		return
+	}
+
+	// Hold the table for the whole press so a double click can't draw twice or pay out twice
+	table.Lock()
+	defer table.Unlock()
+
+	// The round may have ended while this press was waiting on the lock
+	if !table.Started {
+		FollowupPrivate(session, interaction.Interaction, "This round is over.")
+		return
+	}
+
+	// Only players seated at the table can play, and only on their own hands
+	userID := interaction.Member.User.ID
+	seat, ok := table.Round.SeatOf(userID)
+	if !ok {
+		FollowupPrivate(session, interaction.Interaction, "You aren't seated at this table.")
+		return
+	}
+
+	channelID := interaction.ChannelID
+	switch interaction.MessageComponentData().CustomID {
+	case ButtonHit:
+		err = PlayAction(session, channelID, table, seat, blackjack.Hit)
+
+	case ButtonStand:
+		if table.Round.State == blackjack.PlayerTurn && table.Round.Turn == seat {
+			session.ChannelMessageSend(channelID, fmt.Sprintf("%s stands.", table.Names[userID]))
+		}
+
+		err = PlayAction(session, channelID, table, seat, blackjack.Stand)
+
+	case ButtonSplit:
+		err = SplitDecision(session, channelID, table, seat)
+
+	case ButtonDouble:
+		err = DoubleDecision(session, channelID, table, seat)
+
+	case ButtonInsurance:
+		err = InsuranceDecision(session, channelID, table, seat, table.Round.Seats[seat].MaxInsurance())
+
+	case ButtonEvenMoney:
+		err = EvenMoneyDecision(session, channelID, table, seat)
+
+	case ButtonSurrender:
+		err = SurrenderDecision(session, channelID, table, seat)
+
+	case ButtonDecline:
+		// Declines insurance, even money or early surrender
+		err = DeclineDecision(session, channelID, table, seat)
+
+	case ButtonLeave:
+		err = ForfeitSeat(session, channelID, table, seat)
+	}
+
+	// Everyone can press the buttons, so tell the player why their move wasn't played
+	if err != nil {
+		FollowupPrivate(session, interaction.Interaction, RoundErrorMessage(table, seat, err))
+	}
+}
+
+// RoundErrorMessage Explains why the round turned down the seat's move
+func RoundErrorMessage(table *Table, seat int, err error) string {
+	round := table.Round
+	offer := round.State == blackjack.InsuranceOffer || round.State == blackjack.SurrenderOffer
+
+	switch {
+	case err == blackjack.ErrNotYourTurn:
+		return fmt.Sprintf("It's %s's turn.", table.Names[round.Seats[round.Turn].PlayerID])
+	case err == blackjack.ErrIllegalAction:
+		return "You can't make that move on this hand."
+	case err == blackjack.ErrWrongState && offer && round.Seats[seat].Decided:
+		return "You already decided, the dealer is waiting on the other players."
+	case err == blackjack.ErrWrongState && offer:
+		return "Decide on the dealer's offer first."
+	case err == blackjack.ErrWrongState && round.State == blackjack.PlayerTurn:
+		return "The dealer's offer is over, play your hand on your turn."
+	case err == blackjack.ErrWrongState:
+		return "That move can't be made any more this round."
+	}
+
+	return fmt.Sprintf("Can't make that move: %v.", err)
+}
+
+// RespondPrivate Answers an interaction with a message only the user that pressed or typed it can see
+func RespondPrivate(session *discordgo.Session, interaction *discordgo.Interaction, content string) {
+	err := session.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error answering interaction:", err)
+	}
+}
+
+// FollowupPrivate Sends a message only the user can see to an interaction that was already acknowledged
+func FollowupPrivate(session *discordgo.Session, interaction *discordgo.Interaction, content string) {
+	_, err := session.FollowupMessageCreate(interaction, false, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		fmt.Println("Error answering interaction:", err)
+	}
+}
@@ -2,49 +2,60 @@ package cards
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
 )
 
 // CARDS_IN_DECK Constants for deck
 var CARDS_IN_DECK int = 52
 
-// Suit contains a suit and a symbol (suits have no ranking in blackjack)
-type Suit struct {
-	Name   string
-	Symbol string // Can use an icon or picture for later
+// Card contains a rank and suit (Ace of Spades, Three of clubs, etc)
+type Card struct {
+	Rank Rank
+	Suit Suit
 }
 
-// Card contains a suit and value (Ace of Spades, Three of clubs, etc)
-type Card struct {
-	Suit  Suit
-	Name  string
-	Value int
+// Value returns what the card counts for in blackjack (aces count as 11 until that would bust the hand)
+func (c Card) Value() int {
+	return c.Rank.Value()
 }
 
 // Helper functions below for comparing and checking
-func (c *Card) IsFaceCard() bool {
-	return c.Name == "Jack" || c.Name == "Queen" || c.Name == "King"
+func (c Card) IsFaceCard() bool {
+	return c.Rank == Jack || c.Rank == Queen || c.Rank == King
 }
 
-func (c *Card) IsAce() bool {
-	return c.Name == "Ace"
+func (c Card) IsAce() bool {
+	return c.Rank == Ace
 }
 
 func (c *Card) LessThan(other *Card) bool {
-	return c.Value < other.Value
+	return c.Value() < other.Value()
 }
 
 func (c *Card) EqualTo(other *Card) bool {
-	return c.Value == other.Value
+	return c.Value() == other.Value()
 }
 
 func (c *Card) GreaterThan(other *Card) bool {
-	return c.Value < other.Value
+	return c.Value() < other.Value()
 }
 
 func (c *Card) ToString() string {
-	return fmt.Sprintf("%v of %v (%d)", c.Name, c.Suit.Name, c.Value)
+	return fmt.Sprintf("%v of %v (%d)", c.Rank, c.Suit, c.Value())
+}
+
+// Code returns the canonical two letter encoding of the card, e.g. "AS" or "TD"
+func (c Card) Code() string {
+	return c.Rank.Code() + c.Suit.Code()
+}
+
+// Symbol returns the card with its suit symbol, e.g. "A♠" or "10♦"
+func (c Card) Symbol() string {
+	rank := c.Rank.Code()
+	if c.Rank == Ten {
+		rank = "10"
+	}
+
+	return rank + c.Suit.Symbol()
 }
 
 // Deck interface for a deck (contains a collection of cards)
@@ -58,50 +69,13 @@ func (deck *Deck) CreateDeck(numDecks int) {
 	deck.Cards = []Card{}
 	deck.Size = CARDS_IN_DECK * numDecks
 
-	// Create 4 types of suits
-	suits := []Suit{
-		Suit{
-			Name:   "Clubs",
-			Symbol: "club",
-		},
-		Suit{
-			Name:   "Spades",
-			Symbol: "spade",
-		},
-		Suit{
-			Name:   "Hearts",
-			Symbol: "heart",
-		},
-		Suit{
-			Name:   "Diamonds",
-			Symbol: "diamond",
-		},
-	}
-
-	// Map of cards with name and values
-	nameValues := make(map[string]int)
-	nameValues["Two"] = 2
-	nameValues["Three"] = 3
-	nameValues["Four"] = 4
-	nameValues["Five"] = 5
-	nameValues["Six"] = 6
-	nameValues["Seven"] = 7
-	nameValues["Eight"] = 8
-	nameValues["Nine"] = 9
-	nameValues["Ten"] = 10
-	nameValues["Jack"] = 10
-	nameValues["Queen"] = 10
-	nameValues["King"] = 10
-	nameValues["Ace"] = 11
-
-	// Create deck with suits x cards
+	// Create deck with suits x cards, the same source always yields the same shuffle since they are in canonical order
 	for i := 0; i < numDecks; i++ {
-		for _, suit := range suits {
-			for name, value := range nameValues {
+		for _, suit := range Suits {
+			for _, rank := range Ranks {
 				deck.Cards = append(deck.Cards, Card{
-					Suit:  suit,
-					Name:  name,
-					Value: value,
+					Rank: rank,
+					Suit: suit,
 				})
 			}
 		}
@@ -119,7 +93,7 @@ func (deck *Deck) PrintDeck() {
 // PrintArrayDeck prints deck of cards as an array
 func (deck *Deck) PrintArrayDeck() {
 	for _, card := range deck.Cards {
-		fmt.Print(card.Value, " ")
+		fmt.Print(card.Value(), " ")
 	}
 	fmt.Println()
 }
@@ -129,26 +103,17 @@ func (deck *Deck) CardsRemaining() int {
 	return len(deck.Cards)
 }
 
-// Reshuffle re-shuffles the deck
-func (deck *Deck) Reshuffle() {
-	// Seed random number generator
-	rand.Seed(time.Now().Unix())
-
-	// Shuffle function takes in length of array and anonymous swap function
-	rand.Shuffle(len(deck.Cards), func(i, j int) {
+// Reshuffle re-shuffles the deck with the given random source (Fisher-Yates)
+func (deck *Deck) Reshuffle(source RandomSource) {
+	for i := len(deck.Cards) - 1; i > 0; i-- {
+		j := source.Intn(i + 1)
 		deck.Cards[i], deck.Cards[j] = deck.Cards[j], deck.Cards[i]
-	})
+	}
 }
 
 // DrawCard grabs a card from the front of the deck, reduce deck size by 1
+// NOTE: the deck must not be empty
 func (deck *Deck) DrawCard() Card {
-
-	// If less than 20 cards, then create a new deck
-	if deck.CardsRemaining() < 20 {
-		deck.CreateDeck(1)
-		deck.Reshuffle()
-	}
-
 	card := deck.Cards[0]
 
 	deck.Cards = deck.Cards[1:len(deck.Cards)]
@@ -0,0 +1,87 @@
+package cards
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// Provably fair shuffling (commit-reveal)
+//
+// Before a shoe is dealt the server picks a secret server seed and publishes its SHA-256 hash. The shoe is
+// shuffled with a random stream of HMAC-SHA256(server seed, "client seed:counter") blocks, so the order
+// depends on the client seed the players chose as well. Once the shoe is finished the server seed is
+// revealed and anyone can check it against the hash and recompute the order with ShuffledCards.
+
+// NewServerSeed returns a new secret server seed (32 random bytes, hex encoded).
+// Like CryptoSource it panics if the operating system can't supply random bytes.
+func NewServerSeed() string {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(seed)
+}
+
+// HashSeed returns the hex encoded SHA-256 hash of a seed, published before the shoe is dealt
+func HashSeed(seed string) string {
+	hash := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(hash[:])
+}
+
+// FairSource is a random source derived from a server seed and a client seed
+type FairSource struct {
+	serverSeed string
+	clientSeed string
+	counter    uint64 // Number of HMAC blocks generated so far
+	block      []byte // Unused bytes of the current block
+}
+
+// NewFairSource returns the random stream for the pair of seeds, the same seeds always give the same stream
+func NewFairSource(serverSeed string, clientSeed string) *FairSource {
+	return &FairSource{
+		serverSeed: serverSeed,
+		clientSeed: clientSeed,
+	}
+}
+
+// Intn returns a random number in [0, n), rejecting values that would favour the low numbers
+func (source *FairSource) Intn(n int) int {
+	max := uint64(n)
+	limit := ^uint64(0) - ^uint64(0)%max
+
+	for {
+		value := source.next()
+		if value < limit {
+			return int(value % max)
+		}
+	}
+}
+
+// next returns the next 8 bytes of the stream as a number
+func (source *FairSource) next() uint64 {
+	if len(source.block) < 8 {
+		mac := hmac.New(sha256.New, []byte(source.serverSeed))
+		fmt.Fprintf(mac, "%s:%d", source.clientSeed, source.counter)
+		source.block = mac.Sum(nil)
+		source.counter++
+	}
+
+	value := binary.BigEndian.Uint64(source.block[:8])
+	source.block = source.block[8:]
+
+	return value
+}
+
+// ShuffledCards recomputes the order of a shoe from its seeds, used to verify a shoe once its server seed is revealed
+func ShuffledCards(decks int, serverSeed string, clientSeed string) []Card {
+	var deck Deck
+	deck.CreateDeck(decks)
+	deck.Reshuffle(NewFairSource(serverSeed, clientSeed))
+
+	return deck.Cards
+}
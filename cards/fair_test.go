@@ -0,0 +1,54 @@
+package cards
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	testServerSeed = "3f1c9a6e0b5d4e2f8a7c6b5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f"
+	testClientSeed = "lucky table"
+)
+
+func TestHashSeed(t *testing.T) {
+	want := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if hash := HashSeed(""); hash != want {
+		t.Fatalf("HashSeed(\"\") = %s, want %s", hash, want)
+	}
+}
+
+func TestFairSourceIsDeterministic(t *testing.T) {
+	first := NewFairSource(testServerSeed, testClientSeed)
+	second := NewFairSource(testServerSeed, testClientSeed)
+
+	for i := 0; i < 1000; i++ {
+		n := i%52 + 1
+		a, b := first.Intn(n), second.Intn(n)
+		if a != b {
+			t.Fatalf("draw %d: same seeds gave %d and %d", i, a, b)
+		}
+		if a < 0 || a >= n {
+			t.Fatalf("draw %d: Intn(%d) returned %d", i, n, a)
+		}
+	}
+}
+
+func TestShuffledCardsMatchesFairShoe(t *testing.T) {
+	shoe := NewShoe(6, 75, nil)
+	shoe.ShuffleFair(testServerSeed, testClientSeed)
+
+	// The cards dealt from the shoe are the ones a player recomputes from the revealed seeds
+	cards := ShuffledCards(6, testServerSeed, testClientSeed)
+	for index, want := range cards {
+		if card := shoe.DrawCard(); card != want {
+			t.Fatalf("card %d: shoe dealt %s, ShuffledCards has %s", index, card.Code(), want.Code())
+		}
+	}
+
+	if !reflect.DeepEqual(cards, ShuffledCards(6, testServerSeed, testClientSeed)) {
+		t.Fatal("ShuffledCards gave a different order for the same seeds")
+	}
+	if reflect.DeepEqual(cards, ShuffledCards(6, testServerSeed, "another seed")) {
+		t.Fatal("a different client seed gave the same order")
+	}
+}
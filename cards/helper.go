@@ -1,74 +1,122 @@
 package cards
 
 import (
+	"fmt"
 	"strconv"
 )
 
 // Constants for emojis
 const (
 	STOP_SIGN_EMOJI  = "\U0001F6D1" // For quitting the game 🛑
-	CHECKBOX_APPROVE = "\U00002705" // For approving double bet ✅
-	CHECKBOX_DECLINE = "\U0000274C" // For declining double bet ❌
+	CHECKBOX_DECLINE = "\U0000274C" // For declining insurance, even money or early surrender ❌
 	TAP_HIT          = "\U0001F446" // Hit in game 👆
 	TAP_STAND        = "\U0000270B" // Stand in game ✋
+	SPLIT_HAND       = "\U0001F500" // Split a pair in game 🔀
+	DOUBLE_DOWN      = "\U0001F4B0" // Double down in game 💰
+	INSURANCE        = "\U0001F4B5" // Take insurance when the dealer shows an ace 💵
+	EVEN_MONEY       = "\U0001F91D" // Take even money on a blackjack against a dealer ace 🤝
+	SURRENDER        = "\U0001F44B" // Surrender the hand for half the bet 👋
 )
 
-// DealStartingHand Deals initial hand to dealer and player (2 cards each)
-func DealStartingHand(deck *Deck) ([]Card, []Card) {
-	var myHand []Card
+// DealStartingHands Deals initial hands to every player and the dealer, one card at a time around the table
+func DealStartingHands(shoe *Shoe, players int) ([][]Card, []Card) {
+	playerHands := make([][]Card, players)
 	var dealerHand []Card
 
-	card1 := deck.DrawCard()
-	card2 := deck.DrawCard()
-	card3 := deck.DrawCard()
-	card4 := deck.DrawCard()
-
-	myHand = append(myHand, card1, card3)
-	dealerHand = append(dealerHand, card2, card4)
+	for round := 0; round < 2; round++ {
+		for player := range playerHands {
+			playerHands[player] = append(playerHands[player], shoe.DrawCard())
+		}
+		dealerHand = append(dealerHand, shoe.DrawCard())
+	}
 
-	return myHand, dealerHand
+	return playerHands, dealerHand
 }
 
-// IsBlackjack checks if player is dealt a blackjack (Ace + K,Q,J)
+// IsBlackjack checks if the cards are a blackjack (an Ace + 10,J,Q,K and nothing else)
+// NOTE: only a starting hand is a natural blackjack, see EvaluateSplit for hands made by splitting a pair
 func IsBlackjack(hand []Card) bool {
-	return (hand[0].IsAce() && hand[1].IsFaceCard()) || (hand[1].IsAce() && hand[0].IsFaceCard())
+	if len(hand) != 2 {
+		return false
+	}
+
+	return (hand[0].IsAce() && hand[1].Rank.IsTen()) || (hand[1].IsAce() && hand[0].Rank.IsTen())
 }
 
-// HandValue returns the numeric value of the hand (two numbers if aces are in play - adjusts so hand doesn't bust)
-func HandValue(hand []Card) int {
-	var handValue int = 0
+// HandResult is the evaluation of a hand
+type HandResult struct {
+	Total     int  // Best value of the hand
+	Soft      bool // An ace is being counted as 11 (the hand can't bust on the next card)
+	Blackjack bool // Hand is a natural blackjack (two card 21 that wasn't made by splitting)
+	Bust      bool // Hand is over 21
+}
+
+// String returns the total of the hand, e.g. "soft 17", "20", "bust (24)"
+func (result HandResult) String() string {
+	switch {
+	case result.Blackjack:
+		return "blackjack"
+	case result.Bust:
+		return fmt.Sprintf("bust (%d)", result.Total)
+	case result.Soft:
+		return fmt.Sprintf("soft %d", result.Total)
+	}
+
+	return strconv.Itoa(result.Total)
+}
 
-	// Scan through to check number of aces in hand
+// Evaluate returns the total of the hand and whether it is soft, a blackjack or a bust
+func Evaluate(hand []Card) HandResult {
+	total := 0
+
+	// Count every ace as 1 to start with
 	numAces := 0
 	for _, card := range hand {
-		if card.Name == "Ace" {
+		if card.IsAce() {
 			numAces++
+			total++
 		} else {
-			handValue += card.Value
+			total += card.Value()
 		}
 	}
 
-	// Add 11 as value of ace unless it goes over 21
-	for i := 0; i < numAces; i++ {
-		if (handValue + 11) > 21 {
-			handValue++
-		} else {
-			handValue += 11
-		}
+	// At most one ace can be counted as 11 without going over 21
+	soft := false
+	if numAces > 0 && total+10 <= 21 {
+		total += 10
+		soft = true
+	}
+
+	return HandResult{
+		Total:     total,
+		Soft:      soft,
+		Blackjack: IsBlackjack(hand),
+		Bust:      total > 21,
 	}
+}
+
+// EvaluateSplit evaluates a hand made by splitting a pair, an Ace and a ten-value card is just 21 there
+func EvaluateSplit(hand []Card) HandResult {
+	result := Evaluate(hand)
+	result.Blackjack = false
 
-	return handValue
+	return result
+}
+
+// HandValue returns the numeric value of the hand (aces count as 11 unless that would bust the hand)
+func HandValue(hand []Card) int {
+	return Evaluate(hand).Total
 }
 
 // IsBust returns if a player's hand is over 21 (bust)
 func IsBust(hand []Card) bool {
-	return HandValue(hand) > 21
+	return Evaluate(hand).Bust
 }
 
 // ContainsAce returns true if ace is found in player's hand, otherwise false
 func ContainsAce(hand []Card) bool {
 	for _, card := range hand {
-		if card.Name == "Ace" {
+		if card.IsAce() {
 			return true
 		}
 	}
@@ -82,9 +130,9 @@ func PrintHand(hand []Card) string {
 
 	for index, card := range hand {
 		if card.IsFaceCard() || card.IsAce() {
-			handString += card.Name
+			handString += card.Rank.String()
 		} else {
-			handString += strconv.Itoa(card.Value)
+			handString += strconv.Itoa(card.Value())
 		}
 
 		if index < len(hand)-1 {
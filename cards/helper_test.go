@@ -0,0 +1,70 @@
+package cards
+
+import "testing"
+
+// isNatural returns true if the two ranks are an Ace and a ten-value card, in either order
+func isNatural(first Rank, second Rank) bool {
+	return (first == Ace && second.IsTen()) || (second == Ace && first.IsTen())
+}
+
+func TestNaturalBlackjackOverAllRanks(t *testing.T) {
+	for _, first := range Ranks {
+		for _, second := range Ranks {
+			hand := []Card{{Rank: first, Suit: Spades}, {Rank: second, Suit: Hearts}}
+			want := isNatural(first, second)
+
+			if got := IsBlackjack(hand); got != want {
+				t.Errorf("IsBlackjack(%s %s) = %v, want %v", first, second, got, want)
+			}
+			if got := Evaluate(hand).Blackjack; got != want {
+				t.Errorf("Evaluate(%s %s).Blackjack = %v, want %v", first, second, got, want)
+			}
+
+			// A split hand can total 21 with two cards but is never a natural
+			split := EvaluateSplit(hand)
+			if split.Blackjack {
+				t.Errorf("EvaluateSplit(%s %s).Blackjack = true", first, second)
+			}
+			if want && split.Total != 21 {
+				t.Errorf("EvaluateSplit(%s %s).Total = %d, want 21", first, second, split.Total)
+			}
+		}
+	}
+}
+
+func TestBlackjackNeedsExactlyTwoCards(t *testing.T) {
+	aceOfSpades := Card{Rank: Ace, Suit: Spades}
+	kingOfHearts := Card{Rank: King, Suit: Hearts}
+
+	hands := map[string][]Card{
+		"nil":         nil,
+		"empty":       {},
+		"ace":         {aceOfSpades},
+		"king":        {kingOfHearts},
+		"ace king 10": {aceOfSpades, kingOfHearts, {Rank: Ten, Suit: Clubs}},
+	}
+
+	// Three card 21s, e.g. 7-7-7 or Ace-5-5
+	for _, first := range Ranks {
+		for _, second := range Ranks {
+			for _, third := range Ranks {
+				hand := []Card{{Rank: first, Suit: Clubs}, {Rank: second, Suit: Diamonds}, {Rank: third, Suit: Hearts}}
+				if HandValue(hand) == 21 {
+					hands[EncodeCards(hand)] = hand
+				}
+			}
+		}
+	}
+
+	for name, hand := range hands {
+		if IsBlackjack(hand) {
+			t.Errorf("IsBlackjack(%s) = true", name)
+		}
+		if Evaluate(hand).Blackjack {
+			t.Errorf("Evaluate(%s).Blackjack = true", name)
+		}
+		if EvaluateSplit(hand).Blackjack {
+			t.Errorf("EvaluateSplit(%s).Blackjack = true", name)
+		}
+	}
+}
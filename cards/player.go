@@ -1,14 +0,0 @@
-package cards
-
-type Player struct{
-	Name 	string
-	GuildID string
-	Credits int
-	Wins 	int
-	Losses 	int
-	Rank	Rank
-
-	//User	discordgo.User
-}
-
-
@@ -0,0 +1,35 @@
+package cards
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	"math/rand"
+)
+
+// RandomSource picks the random numbers used to shuffle cards
+type RandomSource interface {
+	// Intn returns a random number in [0, n)
+	Intn(n int) int
+}
+
+// DefaultSource is the random source shoes are shuffled with unless one is given
+var DefaultSource RandomSource = CryptoSource{}
+
+// CryptoSource draws from the operating system's cryptographically secure random number generator
+type CryptoSource struct{}
+
+// Intn returns a uniformly random number in [0, n)
+func (CryptoSource) Intn(n int) int {
+	number, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// Nothing can be dealt fairly without a working random source
+		panic(err)
+	}
+
+	return int(number.Int64())
+}
+
+// NewSeededSource returns a source that always produces the same numbers for the same seed, for tests and replays
+func NewSeededSource(seed int64) RandomSource {
+	return rand.New(rand.NewSource(seed))
+}
@@ -0,0 +1,44 @@
+package cards
+
+import (
+	"reflect"
+	"testing"
+)
+
+// shuffledDeck returns a deck of the given size shuffled with the source
+func shuffledDeck(decks int, source RandomSource) []Card {
+	var deck Deck
+	deck.CreateDeck(decks)
+	deck.Reshuffle(source)
+
+	return deck.Cards
+}
+
+func TestSeededSourceRepeatsDeckOrder(t *testing.T) {
+	first := shuffledDeck(6, NewSeededSource(42))
+	second := shuffledDeck(6, NewSeededSource(42))
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("the same seed shuffled the deck in different orders")
+	}
+
+	other := shuffledDeck(6, NewSeededSource(43))
+	if reflect.DeepEqual(first, other) {
+		t.Fatal("different seeds shuffled the deck in the same order")
+	}
+}
+
+func TestReshuffleKeepsEveryCard(t *testing.T) {
+	counts := make(map[Card]int)
+	for _, card := range shuffledDeck(2, NewSeededSource(7)) {
+		counts[card]++
+	}
+
+	if len(counts) != CARDS_IN_DECK {
+		t.Fatalf("shuffled deck has %d distinct cards, want %d", len(counts), CARDS_IN_DECK)
+	}
+	for card, count := range counts {
+		if count != 2 {
+			t.Errorf("%s appears %d times in two decks", card.Code(), count)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package cards
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rank is the rank of a card (Two through Ace)
+type Rank int
+
+const (
+	Two Rank = iota + 2
+	Three
+	Four
+	Five
+	Six
+	Seven
+	Eight
+	Nine
+	Ten
+	Jack
+	Queen
+	King
+	Ace
+)
+
+// Suit is the suit of a card (suits have no ranking in blackjack)
+type Suit int
+
+const (
+	Clubs Suit = iota
+	Spades
+	Hearts
+	Diamonds
+)
+
+// Ranks and Suits list every rank and suit in the canonical order decks are built in.
+// NOTE: shoes are verified by rebuilding them in this order, changing it breaks the check for recorded shoes
+var (
+	Ranks = []Rank{Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King, Ace}
+	Suits = []Suit{Clubs, Spades, Hearts, Diamonds}
+)
+
+// ErrInvalidCard is returned when a rank, suit or card can't be parsed
+var ErrInvalidCard = errors.New("not a valid card")
+
+// rankCodes and suitCodes are the canonical one letter encodings, e.g. "A" + "S" for the ace of spades
+var (
+	rankCodes = map[Rank]string{
+		Two: "2", Three: "3", Four: "4", Five: "5", Six: "6", Seven: "7", Eight: "8",
+		Nine: "9", Ten: "T", Jack: "J", Queen: "Q", King: "K", Ace: "A",
+	}
+	rankNames = map[Rank]string{
+		Two: "Two", Three: "Three", Four: "Four", Five: "Five", Six: "Six", Seven: "Seven", Eight: "Eight",
+		Nine: "Nine", Ten: "Ten", Jack: "Jack", Queen: "Queen", King: "King", Ace: "Ace",
+	}
+	suitCodes   = map[Suit]string{Clubs: "C", Spades: "S", Hearts: "H", Diamonds: "D"}
+	suitNames   = map[Suit]string{Clubs: "Clubs", Spades: "Spades", Hearts: "Hearts", Diamonds: "Diamonds"}
+	suitSymbols = map[Suit]string{Clubs: "♣", Spades: "♠", Hearts: "♥", Diamonds: "♦"}
+)
+
+// Code returns the one letter code of the rank ("2" to "9", "T", "J", "Q", "K", "A")
+func (rank Rank) Code() string {
+	return rankCodes[rank]
+}
+
+// String returns the name of the rank, e.g. "Ace"
+func (rank Rank) String() string {
+	return rankNames[rank]
+}
+
+// IsTen returns true if the rank counts for 10 (Ten, Jack, Queen or King)
+func (rank Rank) IsTen() bool {
+	return rank >= Ten && rank <= King
+}
+
+// ParseRank reads a rank from its code ("A", "T", "10", "7") or name ("Ace", "seven"), ignoring case
+func ParseRank(text string) (Rank, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if text == "10" {
+		return Ten, nil
+	}
+
+	for _, rank := range Ranks {
+		if text == rank.Code() || text == strings.ToUpper(rank.String()) {
+			return rank, nil
+		}
+	}
+
+	return 0, ErrInvalidCard
+}
+
+// Value returns what the rank counts for in blackjack (aces count as 11 until that would bust the hand)
+func (rank Rank) Value() int {
+	switch {
+	case rank == Ace:
+		return 11
+	case rank >= Ten:
+		return 10
+	}
+
+	return int(rank)
+}
+
+// Code returns the one letter code of the suit ("C", "S", "H", "D")
+func (suit Suit) Code() string {
+	return suitCodes[suit]
+}
+
+// String returns the name of the suit, e.g. "Spades"
+func (suit Suit) String() string {
+	return suitNames[suit]
+}
+
+// Symbol returns the Unicode symbol of the suit, e.g. "♠"
+func (suit Suit) Symbol() string {
+	return suitSymbols[suit]
+}
+
+// ParseSuit reads a suit from its code ("S"), name ("Spades", "spade") or symbol ("♠"), ignoring case
+func ParseSuit(text string) (Suit, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+
+	for _, suit := range Suits {
+		name := strings.ToUpper(suit.String())
+		if text == suit.Code() || text == suit.Symbol() || text == name || text == strings.TrimSuffix(name, "S") {
+			return suit, nil
+		}
+	}
+
+	return 0, ErrInvalidCard
+}
+
+// ParseCard reads a card from its canonical code or symbol, e.g. "AS", "TD", "10H" or "A♠"
+func ParseCard(code string) (Card, error) {
+	code = strings.TrimSpace(code)
+
+	// The suit is the last character, which is several bytes long for a symbol
+	suitCode, size := utf8.DecodeLastRuneInString(code)
+	if suitCode == utf8.RuneError || size == len(code) {
+		return Card{}, ErrInvalidCard
+	}
+
+	rank, err := ParseRank(code[:len(code)-size])
+	if err != nil {
+		return Card{}, err
+	}
+
+	suit, err := ParseSuit(code[len(code)-size:])
+	if err != nil {
+		return Card{}, err
+	}
+
+	return Card{Rank: rank, Suit: suit}, nil
+}
+
+// DecodeCards reads cards back from their canonical encoding, e.g. "AS TD 9H"
+func DecodeCards(codes string) ([]Card, error) {
+	var hand []Card
+	for _, code := range strings.Fields(codes) {
+		card, err := ParseCard(code)
+		if err != nil {
+			return nil, err
+		}
+		hand = append(hand, card)
+	}
+
+	return hand, nil
+}
+
+// EncodeCards returns the canonical encoding of the cards for hand histories, e.g. "AS TD 9H"
+func EncodeCards(hand []Card) string {
+	codes := make([]string, len(hand))
+	for index, card := range hand {
+		codes[index] = card.Code()
+	}
+
+	return strings.Join(codes, " ")
+}
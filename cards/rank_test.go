@@ -0,0 +1,28 @@
+package cards
+
+import "testing"
+
+func TestParseCardRoundTrip(t *testing.T) {
+	for _, suit := range Suits {
+		for _, rank := range Ranks {
+			card := Card{Rank: rank, Suit: suit}
+
+			for _, text := range []string{card.Code(), card.Symbol()} {
+				parsed, err := ParseCard(text)
+				if err != nil {
+					t.Errorf("ParseCard(%q) returned %v", text, err)
+				} else if parsed != card {
+					t.Errorf("ParseCard(%q) = %v, want %v", text, parsed, card)
+				}
+			}
+		}
+	}
+}
+
+func TestParseCardInvalid(t *testing.T) {
+	for _, text := range []string{"", "A", "♠", "1S", "AX", "A♥♥", "ZZ"} {
+		if _, err := ParseCard(text); err != ErrInvalidCard {
+			t.Errorf("ParseCard(%q) returned %v, want ErrInvalidCard", text, err)
+		}
+	}
+}
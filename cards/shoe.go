@@ -0,0 +1,77 @@
+package cards
+
+// Shoe holds the decks a table deals from across rounds. A cut card is placed at the penetration point,
+// once it comes out the shoe is reshuffled before the next round (never in the middle of one).
+type Shoe struct {
+	Deck        Deck
+	Decks       int // Number of decks in the shoe
+	Penetration int // Percent of the shoe dealt before the cut card comes out
+	CutCard     int // Number of cards dealt when the cut card is reached
+	Dealt       int // Cards dealt since the last shuffle
+
+	Source RandomSource // Random numbers the shoe is shuffled with
+
+	ServerSeed string // Secret seed of a provably fair shuffle, only revealed once the shoe is finished
+	ClientSeed string // Seed chosen by the players for a provably fair shuffle
+}
+
+// NewShoe returns a freshly shuffled shoe with the given number of decks and penetration.
+// A nil source shuffles with DefaultSource.
+func NewShoe(decks int, penetration int, source RandomSource) *Shoe {
+	if source == nil {
+		source = DefaultSource
+	}
+
+	shoe := &Shoe{
+		Decks:       decks,
+		Penetration: penetration,
+		Source:      source,
+	}
+	shoe.Shuffle()
+
+	return shoe
+}
+
+// Shuffle puts every card back in the shoe, shuffles it and places the cut card
+func (shoe *Shoe) Shuffle() {
+	shoe.Deck.CreateDeck(shoe.Decks)
+	shoe.Deck.Reshuffle(shoe.Source)
+	shoe.CutCard = shoe.Deck.Size * shoe.Penetration / 100
+	shoe.Dealt = 0
+}
+
+// ShuffleFair shuffles the shoe from a server seed and the players' client seed so the order can be verified
+// with ShuffledCards once the server seed is revealed
+func (shoe *Shoe) ShuffleFair(serverSeed string, clientSeed string) {
+	shoe.ServerSeed = serverSeed
+	shoe.ClientSeed = clientSeed
+	shoe.Source = NewFairSource(serverSeed, clientSeed)
+	shoe.Shuffle()
+}
+
+// ServerSeedHash returns the published hash of the shoe's server seed
+func (shoe *Shoe) ServerSeedHash() string {
+	return HashSeed(shoe.ServerSeed)
+}
+
+// NeedsShuffle returns true once the cut card has come out, the shoe should be shuffled before the next round
+func (shoe *Shoe) NeedsShuffle() bool {
+	return shoe.Dealt >= shoe.CutCard
+}
+
+// DrawCard deals the next card from the shoe
+// NOTE: the shoe must not be empty, tables reshuffle between rounds if the shoe might not cover the next one
+func (shoe *Shoe) DrawCard() Card {
+	shoe.Dealt++
+	return shoe.Deck.DrawCard()
+}
+
+// CardsRemaining returns the number of cards left to deal
+func (shoe *Shoe) CardsRemaining() int {
+	return shoe.Deck.CardsRemaining()
+}
+
+// DecksRemaining returns the number of decks left to deal, useful for converting a running count to a true count
+func (shoe *Shoe) DecksRemaining() float64 {
+	return float64(shoe.CardsRemaining()) / float64(CARDS_IN_DECK)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CommandContext holds who ran a command and where, and answers them the same way
+// whether the command was typed with the !game prefix or used as a slash command
+type CommandContext struct {
+	Session   *discordgo.Session
+	GuildID   string
+	ChannelID string
+	UserID    string
+	Username  string
+
+	interaction *discordgo.Interaction // Slash command being answered, nil for prefix commands
+	responded   bool                   // The interaction already has its first response
+	deferred    bool                   // The first response is a loading message the next answer replaces
+	private     bool                   // The loading message is only shown to the user
+}
+
+// NewMessageContext returns the context of a command typed in a message
+func NewMessageContext(session *discordgo.Session, msg *discordgo.MessageCreate) *CommandContext {
+	return &CommandContext{
+		Session:   session,
+		GuildID:   msg.GuildID,
+		ChannelID: msg.ChannelID,
+		UserID:    msg.Author.ID,
+		Username:  msg.Author.Username,
+	}
+}
+
+// NewInteractionContext returns the context of a slash command
+// NOTE: slash commands are only registered in guilds, so the interaction always has a member
+func NewInteractionContext(session *discordgo.Session, interaction *discordgo.InteractionCreate) *CommandContext {
+	return &CommandContext{
+		Session:     session,
+		GuildID:     interaction.GuildID,
+		ChannelID:   interaction.ChannelID,
+		UserID:      interaction.Member.User.ID,
+		Username:    interaction.Member.User.Username,
+		interaction: interaction.Interaction,
+	}
+}
+
+// Reply sends a message everyone in the channel can see
+func (ctx *CommandContext) Reply(content string) {
+	ctx.send(content, nil, false)
+}
+
+// ReplyPrivate sends a message only the user can see (slash commands only, prefix commands reply in the channel)
+func (ctx *CommandContext) ReplyPrivate(content string) {
+	ctx.send(content, nil, true)
+}
+
+// ReplyEmbed sends an embed everyone in the channel can see
+func (ctx *CommandContext) ReplyEmbed(embed *discordgo.MessageEmbed) {
+	ctx.send("", embed, false)
+}
+
+// ReplyPrivateEmbed sends an embed only the user can see (slash commands only, prefix commands reply in the channel)
+func (ctx *CommandContext) ReplyPrivateEmbed(embed *discordgo.MessageEmbed) {
+	ctx.send("", embed, true)
+}
+
+// Defer acknowledges a slash command with a loading message, for commands that may take longer than the
+// 3 seconds Discord waits for a response. The next answer replaces the loading message.
+func (ctx *CommandContext) Defer() {
+	ctx.deferResponse(false)
+}
+
+// DeferPrivate acknowledges a slash command with a loading message only the user can see, for commands
+// that answer privately. The next private answer replaces it.
+func (ctx *CommandContext) DeferPrivate() {
+	ctx.deferResponse(true)
+}
+
+// deferResponse sends the loading message
+func (ctx *CommandContext) deferResponse(private bool) {
+	if ctx.interaction == nil || ctx.responded {
+		return
+	}
+
+	var flags discordgo.MessageFlags
+	if private {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	err := ctx.Session.InteractionRespond(ctx.interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: flags},
+	})
+	if err != nil {
+		fmt.Println("Error deferring command:", err)
+		return
+	}
+
+	ctx.responded = true
+	ctx.deferred = true
+	ctx.private = private
+}
+
+// send answers the command. The first answer to a slash command is its interaction response, later ones are followups.
+func (ctx *CommandContext) send(content string, embed *discordgo.MessageEmbed, private bool) {
+	var embeds []*discordgo.MessageEmbed
+	if embed != nil {
+		embeds = append(embeds, embed)
+	}
+
+	var err error
+	switch {
+	case ctx.interaction == nil && embed != nil:
+		_, err = ctx.Session.ChannelMessageSendEmbed(ctx.ChannelID, embed)
+
+	case ctx.interaction == nil:
+		_, err = ctx.Session.ChannelMessageSend(ctx.ChannelID, content)
+
+	case ctx.deferred && private == ctx.private:
+		_, err = ctx.Session.InteractionResponseEdit(ctx.interaction, &discordgo.WebhookEdit{
+			Content: &content,
+			Embeds:  &embeds,
+		})
+		ctx.deferred = err != nil
+
+	case !ctx.responded:
+		var flags discordgo.MessageFlags
+		if private {
+			flags = discordgo.MessageFlagsEphemeral
+		}
+
+		err = ctx.Session.InteractionRespond(ctx.interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Embeds:  embeds,
+				Flags:   flags,
+			},
+		})
+		ctx.responded = err == nil
+
+	default:
+		var flags discordgo.MessageFlags
+		if private {
+			flags = discordgo.MessageFlagsEphemeral
+		}
+
+		_, err = ctx.Session.FollowupMessageCreate(ctx.interaction, false, &discordgo.WebhookParams{
+			Content: content,
+			Embeds:  embeds,
+			Flags:   flags,
+		})
+
+		// The loading message can't change who sees it, so an answer for a different audience removes it instead
+		if err == nil && ctx.deferred {
+			err = ctx.Session.InteractionResponseDelete(ctx.interaction)
+			ctx.deferred = false
+		}
+	}
+
+	if err != nil {
+		fmt.Println("Error replying to command:", err)
+	}
+}
+
+// Prefix returns the prefix commands are typed with in the guild
+func (ctx *CommandContext) Prefix() string {
+	return GuildPrefixes.Get(ctx.GuildID)
+}
+
+// ProfileKey returns the key of the profile the user plays with in the guild
+func (ctx *CommandContext) ProfileKey() ProfileKey {
+	return ProfileKeyOf(ctx.GuildID, ctx.UserID)
+}
@@ -0,0 +1,159 @@
+package main
+
+// Commands - Holds every command players can type after the guild's prefix
+var Commands = NewCommandRegistry()
+
+// RegisterCommands Registers the game commands, in the order help lists them
+func RegisterCommands() {
+	Commands.Register(&Command{
+		Name:        "help",
+		Aliases:     []string{"commands"},
+		Description: "Display a list of commands, or how to use one of them",
+		Args:        []Arg{{Name: "command", Type: WordArg, Optional: true}},
+		Run:         HelpCommand,
+	})
+
+	Commands.Register(&Command{
+		Name:        "blackjack",
+		Aliases:     []string{"bj", "play"},
+		Description: "Plays a round of blackjack against the dealer, betting the table minimum if no amount is given",
+		Args:        []Arg{{Name: "amount", Type: AmountArg, Optional: true}},
+		Run: func(ctx *CommandContext, args Args) {
+			PlayBlackjack(ctx, args.Amount("amount", NoBetGiven))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name: "table",
+		Description: "Opens a table with up to 7 seats, joins the next round with a bet, deals right away, " +
+			"gets up from your seat, or closes the table",
+		Args: []Arg{
+			{Name: "action", Type: WordArg, Choices: []string{"open", "join", "deal", "leave", "close"}},
+			{Name: "amount", Type: AmountArg, Optional: true},
+		},
+		Run: func(ctx *CommandContext, args Args) {
+			TableCommand(ctx, args.String("action"), args.Amount("amount", NoBetGiven))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "insure",
+		Aliases:     []string{"insurance"},
+		Description: "Takes insurance of up to half your bet when the dealer shows an Ace",
+		Args:        []Arg{{Name: "amount", Type: AmountArg}},
+		Run: func(ctx *CommandContext, args Args) {
+			InsureCommand(ctx, args.Amount("amount", 0))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "shoe",
+		Description: "Shows the cards and decks left in the table's shoe before the reshuffle",
+		Run: func(ctx *CommandContext, args Args) {
+			ShoeCommand(ctx)
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "seed",
+		Description: "Shows or sets the client seed the next shoe is shuffled with (provably fair)",
+		Args:        []Arg{{Name: "text", Type: TextArg, Optional: true}},
+		Run: func(ctx *CommandContext, args Args) {
+			SeedCommand(ctx, args.String("text"))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "verify",
+		Description: "Recomputes the card order of a finished shoe from its revealed seeds",
+		Args:        []Arg{{Name: "shoeID", Type: AmountArg}},
+		Run: func(ctx *CommandContext, args Args) {
+			VerifyCommand(ctx, int64(args.Amount("shoeID", 0)))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "rules",
+		Description: "Shows the house rules, admins can change one by giving a setting and its value",
+		Args: []Arg{
+			{Name: "setting", Type: WordArg, Optional: true, Choices: RuleSettings},
+			{Name: "value", Type: WordArg, Optional: true},
+		},
+		Run: func(ctx *CommandContext, args Args) {
+			RulesCommand(ctx, args.String("setting"), args.String("value"))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "wallet",
+		Aliases:     []string{"credits", "balance"},
+		Description: "Shows how many credits you have",
+		Run: func(ctx *CommandContext, args Args) {
+			DisplayPlayerCredits(ctx)
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "stats",
+		Description: "Displays your win-loss record and rank",
+		Run: func(ctx *CommandContext, args Args) {
+			DisplayPlayerStats(ctx)
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "save",
+		Description: "Your credits, record and rank are saved automatically, kept for old habits",
+		Run: func(ctx *CommandContext, args Args) {
+			SavePlayerData(ctx)
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "shop",
+		Description: "Displays a list of titles you can purchase",
+		Run: func(ctx *CommandContext, args Args) {
+			DisplayGameShop(ctx)
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "ranks",
+		Aliases:     []string{"titles"},
+		Description: "Lists every rank title in order",
+		Run: func(ctx *CommandContext, args Args) {
+			DisplayRanks(ctx)
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "buy",
+		Aliases:     []string{"purchase"},
+		Description: "Purchases the next rank title from the shop",
+		Args:        []Arg{{Name: "rank", Type: AmountArg}},
+		Run: func(ctx *CommandContext, args Args) {
+			PurchaseRankTitle(ctx, args.Amount("rank", 0))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name: "sharedwallet",
+		Description: "Turns the shared wallet on or off. With it on, players use the same credits, record and rank " +
+			"in every server that has it on",
+		Args:       []Arg{{Name: "setting", Type: WordArg, Choices: []string{"on", "off"}}},
+		Permission: AdminOnly,
+		Run: func(ctx *CommandContext, args Args) {
+			SharedWalletCommand(ctx, args.String("setting"))
+		},
+	})
+
+	Commands.Register(&Command{
+		Name:        "prefix",
+		Description: "Changes the prefix commands are typed with in this server (" + DefaultPrefix + " by default)",
+		Args:        []Arg{{Name: "prefix", Type: WordArg}},
+		Permission:  AdminOnly,
+		Run: func(ctx *CommandContext, args Args) {
+			PrefixCommand(ctx, args.String("prefix"))
+		},
+	})
+}
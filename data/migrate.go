@@ -0,0 +1,220 @@
+package data
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema migrations
+//
+// Every change to the schema is a pair of files in migrations/: NNNN_name.up.sql applies it and
+// NNNN_name.down.sql reverts it. Migrations are applied in version order and recorded in the
+// schema_migrations table. Only SQL that both Postgres and CockroachDB understand can be used.
+// NOTE: never edit a migration that has been released, add a new one instead
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned change to the schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL that applies the change
+	Down    string // SQL that reverts it
+}
+
+// MigrationStatus is a migration and whether it has been applied to the database
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns every embedded migration in version order
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		// e.g. "0001_create_player.up.sql" -> version 1, name "create_player", direction "up"
+		parts := strings.SplitN(strings.TrimSuffix(entry.Name(), ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s isn't named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s doesn't start with a version number", entry.Name())
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+
+		switch {
+		case strings.HasSuffix(parts[1], ".up"):
+			migration.Name = strings.TrimSuffix(parts[1], ".up")
+			migration.Up = string(content)
+		case strings.HasSuffix(parts[1], ".down"):
+			migration.Down = string(content)
+		default:
+			return nil, fmt.Errorf("migration %s isn't an up or down migration", entry.Name())
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// createMigrationsTable creates the table recording which migrations have been applied
+func createMigrationsTable(db *sql.DB) error {
+	sqlCreateTable := `CREATE TABLE IF NOT EXISTS schema_migrations(
+		version    int,
+		name       varchar(100),
+		applied_at timestamptz DEFAULT now(),
+
+		PRIMARY KEY(version));
+	`
+
+	_, err := db.Exec(sqlCreateTable)
+	return err
+}
+
+// MigrationStatuses returns every migration and whether it has been applied
+func MigrationStatuses(db *sql.DB) ([]MigrationStatus, error) {
+	if err := createMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for index, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses[index] = MigrationStatus{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		}
+	}
+
+	return statuses, nil
+}
+
+// MigrateUp applies every migration that hasn't been applied yet, in version order.
+// Returns the migrations that were applied.
+func MigrateUp(db *sql.DB) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+
+		err := runMigration(db, status.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+			status.Version, status.Name)
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %v", status.Version, status.Name, err)
+		}
+		applied = append(applied, status.Migration)
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first. Returns the migrations that were reverted.
+func MigrateDown(db *sql.DB, steps int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for index := len(statuses) - 1; index >= 0 && len(reverted) < steps; index-- {
+		status := statuses[index]
+		if !status.Applied {
+			continue
+		}
+
+		err := runMigration(db, status.Down, `DELETE FROM schema_migrations WHERE version=$1`, status.Version)
+		if err != nil {
+			return reverted, fmt.Errorf("reverting migration %04d_%s: %v", status.Version, status.Name, err)
+		}
+		reverted = append(reverted, status.Migration)
+	}
+
+	return reverted, nil
+}
+
+// runMigration runs the migration's SQL and records it in schema_migrations in one transaction,
+// so a migration that fails part way is neither half applied nor recorded
+func runMigration(db *sql.DB, migration string, record string, args ...interface{}) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(migration); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
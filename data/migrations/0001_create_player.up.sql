@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS Player(
+	user_id   varchar(20),
+	guild_id  varchar(20),
+	username  varchar(70),
+	credits   int,
+	wins      int,
+	losses    int,
+	user_rank int,
+
+	PRIMARY KEY(user_id, guild_id));
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS GuildSettings(
+	guild_id           varchar(20),
+	min_bet            int,
+	max_bet            int,
+	decks              int,
+	penetration        int,
+	hits_soft_17       boolean,
+	blackjack_pays     int,
+	max_split_hands    int,
+	double_on          int,
+	double_after_split boolean,
+	surrender          int,
+
+	PRIMARY KEY(guild_id));
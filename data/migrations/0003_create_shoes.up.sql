@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS Shoes(
+	shoe_id          SERIAL,
+	guild_id         varchar(20),
+	channel_id       varchar(20),
+	decks            int,
+	server_seed_hash varchar(64),
+	server_seed      varchar(64),
+	client_seed      varchar(100),
+	revealed         boolean DEFAULT false,
+
+	PRIMARY KEY(shoe_id));
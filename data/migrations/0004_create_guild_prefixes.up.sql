@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS GuildPrefixes(
+	guild_id varchar(20),
+	prefix   varchar(20),
+
+	PRIMARY KEY(guild_id));
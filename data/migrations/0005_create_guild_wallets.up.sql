@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS GuildWallets(
+	guild_id varchar(20),
+	shared   boolean NOT NULL DEFAULT false,
+
+	PRIMARY KEY(guild_id));
@@ -0,0 +1,824 @@
+package main
+
+import (
+	"discordgo-blackjack/blackjack"
+	"discordgo-blackjack/cards"
+	"discordgo-blackjack/handler"
+	"discordgo-blackjack/profile"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// NoBetGiven is passed as the bet when the player doesn't give an amount, they bet the table minimum
+const NoBetGiven = 0
+
+// PlayBlackjack Takes the player's bet and deals them straight into a round. Opens a table if the channel
+// doesn't have one, or takes a seat if a multi-seat round is still taking bets.
+func PlayBlackjack(ctx *CommandContext, bet int) {
+
+	table, opened := Tables.Open(ctx.ChannelID, ctx.GuildID, ctx.UserID)
+	if !opened {
+		table.Lock()
+	}
+	defer table.Unlock()
+
+	if table.Closed {
+		ctx.Reply("The table was just closed, try again.")
+		return
+	}
+
+	if table.Started {
+		ctx.Reply("A round is already being played at this table. Wait for it to finish.")
+		return
+	}
+
+	// Someone else is collecting bets, sit down and wait for the deal
+	if table.Round != nil {
+		SitDown(ctx, table, bet)
+		return
+	}
+
+	StartRound(table, ctx.GuildID)
+
+	if !SitDown(ctx, table, bet) {
+		table.Round = nil
+
+		// Don't leave an empty table behind in the channel
+		if opened {
+			EndGame(table)
+		}
+		return
+	}
+
+	DealRound(ctx.Session, table)
+
+	// The rest of the round is played from the buttons on the table embed (ButtonHandler)
+}
+
+// TableCommand Runs one of the multi-seat table commands (open, join, deal, leave, close)
+// The bet is only used to join, NoBetGiven if no amount was given.
+func TableCommand(ctx *CommandContext, action string, bet int) {
+
+	if action == "open" {
+		OpenTable(ctx)
+		return
+	}
+
+	table, ok := Tables.ByChannel(ctx.ChannelID)
+	if !ok {
+		ctx.Reply(fmt.Sprintf("There is no table open in this channel. Type \"%s table open\" to open one.", ctx.Prefix()))
+		return
+	}
+
+	table.Lock()
+	defer table.Unlock()
+
+	if table.Closed {
+		return
+	}
+
+	switch action {
+	case "join":
+		JoinTable(ctx, table, bet)
+	case "deal":
+		DealCommand(ctx, table)
+	case "leave":
+		LeaveTable(ctx, table)
+	case "close":
+		CloseTable(ctx, table)
+	}
+}
+
+// OpenTable Opens a table in the channel that up to seven players can sit at
+func OpenTable(ctx *CommandContext) {
+
+	table, ok := Tables.Open(ctx.ChannelID, ctx.GuildID, ctx.UserID)
+	if !ok {
+		ctx.Reply("A table is already open in this channel.")
+		return
+	}
+	defer table.Unlock()
+
+	ctx.Reply(
+		fmt.Sprintf("%s opened a blackjack table with %d seats. Type \"%s table join <amount>\" to sit down and bet.\n%s",
+			ctx.Username, GuildRules.Get(ctx.GuildID).Seats(), ctx.Prefix(), NextShoeCommitment(table)))
+}
+
+// JoinTable Seats the player with their bet. The first player to join starts the join window for the next round.
+func JoinTable(ctx *CommandContext, table *Table, bet int) {
+
+	if table.Started {
+		ctx.Reply("A round is being played. Join once it is settled.")
+		return
+	}
+
+	newRound := table.Round == nil
+	if newRound {
+		StartRound(table, ctx.GuildID)
+	}
+
+	if !SitDown(ctx, table, bet) {
+		if newRound {
+			table.Round = nil
+		}
+		return
+	}
+
+	if newRound {
+		ctx.Reply(
+			fmt.Sprintf("Betting is open for %v. Type \"%s table join <amount>\" to take a seat, "+
+				"or \"%s table deal\" to deal right away.", JoinWindow, ctx.Prefix(), ctx.Prefix()))
+
+		// Deal once the join window closes, unless the round was dealt or cancelled before then
+		round := table.Round
+		table.dealing = time.AfterFunc(JoinWindow, func() {
+			table.Lock()
+			defer table.Unlock()
+
+			if table.Closed || table.Started || table.Round != round {
+				return
+			}
+
+			DealRound(ctx.Session, table)
+		})
+	}
+}
+
+// DealCommand Closes the join window early and deals the round
+func DealCommand(ctx *CommandContext, table *Table) {
+
+	if table.Round == nil || table.Started {
+		ctx.Reply("There is no round taking bets right now.")
+		return
+	}
+
+	// Only a seated player can cut the join window short
+	if _, ok := table.Round.SeatOf(ctx.UserID); !ok {
+		ctx.Reply("Only players seated at the table can deal.")
+		return
+	}
+
+	DealRound(ctx.Session, table)
+}
+
+// LeaveTable Gets the player up from their seat. Their bet is returned before the deal and forfeited after it.
+func LeaveTable(ctx *CommandContext, table *Table) {
+
+	if table.Round == nil {
+		ctx.Reply("You aren't seated at this table.")
+		return
+	}
+
+	seat, ok := table.Round.SeatOf(ctx.UserID)
+	if !ok {
+		ctx.Reply("You aren't seated at this table.")
+		return
+	}
+
+	if table.Started {
+		ForfeitSeat(ctx.Session, ctx.ChannelID, table, seat)
+		return
+	}
+
+	ReturnCredits(table.PlayerKey(ctx.UserID), table.Round.Seats[seat].TotalBet())
+	table.Round.Leave(seat)
+	delete(table.Names, ctx.UserID)
+	delete(table.Keys, ctx.UserID)
+
+	ctx.Reply(fmt.Sprintf("%s leaves the table. Your bet is returned.", ctx.Username))
+}
+
+// CloseTable Closes the channel's table, only the player that opened it or a server admin can close it
+func CloseTable(ctx *CommandContext, table *Table) {
+
+	if ctx.UserID != table.HostID && !IsGuildAdmin(ctx.Session, ctx.UserID, ctx.ChannelID) {
+		ctx.Reply("Only the player that opened the table or a server admin can close it.")
+		return
+	}
+
+	if table.Started {
+		ctx.Reply("Finish the round before closing the table.")
+		return
+	}
+
+	// Give back the bets of anyone waiting on the deal
+	if table.Round != nil {
+		RefundBets(table)
+	}
+
+	// No more cards are dealt from the shoe, so its seed can be checked
+	EndShoe(ctx.Session, table)
+	EndGame(table)
+	ctx.Reply("The table is closed.")
+}
+
+// StartRound Starts taking bets for a new round at the table with the guild's current rules.
+// The shoe is only prepared once the round is dealt, so bets that are turned down don't shuffle a new one.
+func StartRound(table *Table, guildID string) {
+	table.Round = blackjack.NewRound(table.Shoe, GuildRules.Get(guildID))
+	table.Names = make(map[string]string)
+	table.Keys = make(map[string]ProfileKey)
+}
+
+// SitDown Takes the player's bet and gives them a seat in the round. Returns false if they couldn't sit down.
+func SitDown(ctx *CommandContext, table *Table, bet int) bool {
+	rules := table.Round.Rules
+	if bet == NoBetGiven {
+		bet = rules.MinBet
+	}
+
+	if _, seated := table.Round.SeatOf(ctx.UserID); seated {
+		ctx.Reply("You already have a seat at this table.")
+		return false
+	}
+
+	if seats := rules.Seats(); len(table.Round.Seats) >= seats {
+		ctx.Reply(fmt.Sprintf("Every seat at this table is taken (a %d deck shoe seats %d players).", rules.Decks, seats))
+		return false
+	}
+
+	// Escrow the bet so the credits can't be spent while the round is played
+	key := table.PlayerKey(ctx.UserID)
+	err := UserProfiles.Change(key, func(player profile.Player) (handler.PlayerChange, error) {
+		if err := rules.CheckBet(bet, player.Credits); err != nil {
+			return handler.PlayerChange{}, err
+		}
+		return handler.PlayerChange{Credits: -bet}, nil
+	})
+	if err != nil {
+		ctx.Reply(BetErrorMessage(err, rules))
+		return false
+	}
+
+	seat, err := table.Round.Sit(ctx.UserID, bet)
+	if err != nil {
+		ReturnCredits(key, bet)
+		ctx.Reply(fmt.Sprintf("Can't take a seat: %v.", err))
+		return false
+	}
+
+	table.Names[ctx.UserID] = ctx.Username
+	table.Keys[ctx.UserID] = key
+	ctx.Reply(
+		fmt.Sprintf("%s sits down at seat %d and bets %d credits.", ctx.Username, seat+1, bet))
+
+	return true
+}
+
+// DealRound Deals the starting hands to everyone seated and puts the table embed up for the round
+func DealRound(session *discordgo.Session, table *Table) {
+
+	if table.dealing != nil {
+		table.dealing.Stop()
+		table.dealing = nil
+	}
+
+	if len(table.Round.Seats) == 0 {
+		session.ChannelMessageSend(table.ChannelID, "Nobody sat down, so no cards are dealt this round.")
+		table.Round = nil
+		return
+	}
+
+	// The shoe stays with the table between rounds
+	PrepareShoe(session, table, table.Round.Rules)
+	table.Round.Shoe = table.Shoe
+
+	// Deal starting cards
+	events, err := table.Round.Deal()
+	if err != nil {
+		log.Println("Error dealing starting hands:", err)
+		RefundBets(table)
+		return
+	}
+	table.Started = true
+
+	session.ChannelMessageSend(table.ChannelID,
+		fmt.Sprintf("Welcome to Blackjack. Dealing out initial hands to %d player(s).", len(table.Round.Seats)))
+
+	table.Embed = &discordgo.MessageEmbed{
+		Title:       "Blackjack Table",
+		Description: "Two initial cards have been dealt to the dealer and every seat.",
+		Color:       0,
+		Footer:      &discordgo.MessageEmbedFooter{Text: ShoeStatus(table)},
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name: "How to play",
+				Value: "On your turn press Hit, Stand, Double or Split. Surrender gives back half your bet, " +
+					"Leave gets up from the table and forfeits it. Buttons are greyed out when their move isn't allowed.",
+			},
+			DealerField(table.Round),
+		},
+	}
+	table.Embed.Fields = append(table.Embed.Fields, SeatFields(table)...)
+
+	embed, err := session.ChannelMessageSendComplex(table.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{table.Embed},
+		Components: TableButtons(table.Round),
+	})
+	if err != nil {
+		fmt.Println("Error showing table embed")
+		RefundBets(table)
+		return
+	}
+
+	// Route button presses on the table embed to this table
+	Tables.Bind(table, embed.ID)
+
+	// If the dealer peeked a blackjack or every seat has one the round is already settled
+	ReportEvents(session, table.ChannelID, table, events)
+}
+
+// BetErrorMessage Explains to the player why their bet wasn't accepted
+func BetErrorMessage(err error, rules blackjack.TableRules) string {
+	switch err {
+	case blackjack.ErrBetTooSmall:
+		return fmt.Sprintf("The minimum bet at this table is %d credits.", rules.MinBet)
+	case blackjack.ErrBetTooLarge:
+		return fmt.Sprintf("The maximum bet at this table is %d credits.", rules.MaxBet)
+	case blackjack.ErrInsufficientCredits:
+		return "You don't have enough credits to cover that bet."
+	}
+
+	return CreditErrorMessage(err, "You don't have enough credits to cover that bet.")
+}
+
+// CreditErrorMessage Explains to the player why credits couldn't be taken from their wallet
+func CreditErrorMessage(err error, insufficient string) string {
+	switch err {
+	case handler.ErrInsufficientCredits:
+		return insufficient
+	case handler.ErrPlayerNotFound:
+		return NoProfileMessage
+	}
+
+	fmt.Println("Error updating credits:", err)
+	return "Couldn't reach your wallet, try again later."
+}
+
+// RefundBets Gives the escrowed bets back to everyone seated when a round can't be played
+func RefundBets(table *Table) {
+	for _, seat := range table.Round.Seats {
+		ReturnCredits(table.PlayerKey(seat.PlayerID), seat.TotalBet())
+	}
+
+	table.Started = false
+	table.Round = nil
+}
+
+// EndGame Marks the table as finished and removes it from the channel
+func EndGame(table *Table) {
+	if table.dealing != nil {
+		table.dealing.Stop()
+	}
+
+	table.Started = false
+	Tables.Close(table)
+}
+
+// Waits for a reaction and adds a handler to the current session. Returns a channel with the reaction in it.
+// func waitForReaction(session *discordgo.Session) chan *discordgo.MessageReactionAdd {
+// 	channel := make(chan *discordgo.MessageReactionAdd)
+// 	session.AddHandlerOnce(func(_ *discordgo.Session, rxn *discordgo.MessageReactionAdd) {
+// 		channel <- rxn
+// 	})
+// 	return channel
+// }
+
+// PlayAction Plays the action on the seat's active hand and updates the table.
+// Returns the round's error if the move isn't allowed, nothing is changed then.
+func PlayAction(session *discordgo.Session, channelID string, table *Table, seat int, action blackjack.Action) error {
+
+	events, err := table.Round.Apply(seat, action)
+	if err != nil {
+		return err
+	}
+
+	// Update player hands and embed
+	RefreshTableEmbed(session, table)
+
+	// Player may have busted, passed the turn on or finished the round
+	ReportEvents(session, channelID, table, events)
+	return nil
+}
+
+// ForfeitSeat Takes the player out of the round, the house keeps the bets they already placed
+func ForfeitSeat(session *discordgo.Session, channelID string, table *Table, seat int) error {
+
+	player := table.Round.Seats[seat]
+	events, err := table.Round.Leave(seat)
+	if err != nil {
+		return err
+	}
+
+	session.ChannelMessageSend(channelID,
+		fmt.Sprintf("%s leaves the table and forfeits their bet of %d credits.", table.Names[player.PlayerID], player.TotalBet()))
+
+	RefreshTableEmbed(session, table)
+	ReportEvents(session, channelID, table, events)
+	return nil
+}
+
+// SplitDecision Stakes another bet and splits the seat's pair into two hands
+func SplitDecision(session *discordgo.Session, channelID string, table *Table, seat int) error {
+
+	if !table.Round.CanSplit(seat) {
+		session.ChannelMessageSend(channelID, "You can only split a pair on your turn, and only up to the table's hand limit.")
+		return nil
+	}
+
+	// The new hand carries the same bet as the hand being split
+	player := table.Round.Seats[seat]
+	bet := player.ActiveHand().Bet
+	if err := EscrowCredits(table.PlayerKey(player.PlayerID), bet); err != nil {
+		session.ChannelMessageSend(channelID, CreditErrorMessage(err, fmt.Sprintf("You need %d more credits to split.", bet)))
+		return nil
+	}
+
+	session.ChannelMessageSend(channelID, fmt.Sprintf("Splitting your pair. Another %d credits are bet on the new hand.", bet))
+	return PlayAction(session, channelID, table, seat, blackjack.Split)
+}
+
+// DoubleDecision Doubles the bet on the seat's active hand, the player gets exactly one more card
+func DoubleDecision(session *discordgo.Session, channelID string, table *Table, seat int) error {
+
+	if !table.Round.CanDouble(seat) {
+		session.ChannelMessageSend(channelID, "You can't double down on this hand.")
+		return nil
+	}
+
+	player := table.Round.Seats[seat]
+	bet := player.ActiveHand().Bet
+	if err := EscrowCredits(table.PlayerKey(player.PlayerID), bet); err != nil {
+		session.ChannelMessageSend(channelID, CreditErrorMessage(err, fmt.Sprintf("You need %d more credits to double down.", bet)))
+		return nil
+	}
+
+	session.ChannelMessageSend(channelID, fmt.Sprintf("Doubling down. Another %d credits are bet and you get one more card.", bet))
+	return PlayAction(session, channelID, table, seat, blackjack.Double)
+}
+
+// SurrenderDecision Gives up the seat's hand for half of their bet back
+func SurrenderDecision(session *discordgo.Session, channelID string, table *Table, seat int) error {
+
+	if !table.Round.CanSurrender(seat) {
+		session.ChannelMessageSend(channelID, "You can only surrender on your first decision of the hand.")
+		return nil
+	}
+
+	session.ChannelMessageSend(channelID, fmt.Sprintf("%s surrenders.", table.Names[table.Round.Seats[seat].PlayerID]))
+	return PlayAction(session, channelID, table, seat, blackjack.Surrender)
+}
+
+// DeclineDecision Declines insurance, even money or early surrender. The dealer peeks once every seat has decided.
+func DeclineDecision(session *discordgo.Session, channelID string, table *Table, seat int) error {
+
+	events, err := table.Round.Decline(seat)
+	if err != nil {
+		return err
+	}
+
+	session.ChannelMessageSend(channelID, fmt.Sprintf("%s plays on.", table.Names[table.Round.Seats[seat].PlayerID]))
+	RefreshTableEmbed(session, table)
+	ReportEvents(session, channelID, table, events)
+	return nil
+}
+
+// EscrowCredits Takes credits from the player for a bet and saves their wallet.
+// Returns handler.ErrInsufficientCredits if they can't cover it.
+func EscrowCredits(key ProfileKey, amount int) error {
+	return UserProfiles.Commit(handler.PlayerChange{GuildID: key.GuildID, UserID: key.UserID, Credits: -amount})
+}
+
+// ReturnCredits Gives escrowed credits back to the player and saves their wallet (later if it can't be saved now)
+func ReturnCredits(key ProfileKey, amount int) {
+	UserProfiles.CommitEach(handler.PlayerChange{GuildID: key.GuildID, UserID: key.UserID, Credits: amount})
+}
+
+// InsureCommand Places an insurance bet of the given amount on the player's seat at the table in this channel
+func InsureCommand(ctx *CommandContext, amount int) {
+
+	table, ok := Tables.ByChannel(ctx.ChannelID)
+	if !ok {
+		ctx.Reply("There is no game running in this channel.")
+		return
+	}
+
+	table.Lock()
+	defer table.Unlock()
+
+	if !table.Started {
+		ctx.Reply("You don't have a game running in this channel.")
+		return
+	}
+
+	seat, ok := table.Round.SeatOf(ctx.UserID)
+	if !ok {
+		ctx.Reply("You don't have a game running in this channel.")
+		return
+	}
+
+	if err := InsuranceDecision(ctx.Session, ctx.ChannelID, table, seat, amount); err != nil {
+		ctx.Reply(RoundErrorMessage(table, seat, err))
+	}
+}
+
+// InsuranceDecision Takes the seat's insurance bet (0 declines). The dealer peeks once every seat has decided.
+func InsuranceDecision(session *discordgo.Session, channelID string, table *Table, seat int, amount int) error {
+
+	player := table.Round.Seats[seat]
+	if table.Round.State != blackjack.InsuranceOffer || player.Decided {
+		return blackjack.ErrWrongState
+	}
+
+	if amount > player.MaxInsurance() || amount < 0 {
+		session.ChannelMessageSend(channelID,
+			fmt.Sprintf("Insurance can be at most half your bet (%d credits).", player.MaxInsurance()))
+		return nil
+	}
+
+	if amount > 0 {
+		if err := EscrowCredits(table.PlayerKey(player.PlayerID), amount); err != nil {
+			session.ChannelMessageSend(channelID,
+				CreditErrorMessage(err, fmt.Sprintf("You need %d more credits for that insurance bet.", amount)))
+			return nil
+		}
+	}
+
+	events, err := table.Round.Insure(seat, amount)
+	if err != nil {
+		// Give back the insurance bet since it wasn't placed
+		if amount > 0 {
+			ReturnCredits(table.PlayerKey(player.PlayerID), amount)
+		}
+
+		if err == blackjack.ErrIllegalAction {
+			session.ChannelMessageSend(channelID,
+				"You have a blackjack. Press Even Money or Decline.")
+			return nil
+		}
+		return err
+	}
+
+	name := table.Names[player.PlayerID]
+	if amount > 0 {
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%s insures their hand for %d credits.", name, amount))
+	} else {
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%s takes no insurance.", name))
+	}
+
+	RefreshTableEmbed(session, table)
+	ReportEvents(session, channelID, table, events)
+	return nil
+}
+
+// EvenMoneyDecision Pays the seat's blackjack at 1:1 before the dealer peeks
+func EvenMoneyDecision(session *discordgo.Session, channelID string, table *Table, seat int) error {
+
+	events, err := table.Round.TakeEvenMoney(seat)
+	if err != nil {
+		return err
+	}
+
+	RefreshTableEmbed(session, table)
+	ReportEvents(session, channelID, table, events)
+	return nil
+}
+
+// DealerField Returns an embed field with the dealer's up card, or their whole hand once they have played
+func DealerField(round *blackjack.Round) *discordgo.MessageEmbedField {
+	value := fmt.Sprintf("%s + hidden card", cards.PrintHand([]cards.Card{round.DealerUpCard()}))
+	if round.State == blackjack.DealerTurn || round.State == blackjack.Settled {
+		value = fmt.Sprintf("%s (%v)", cards.PrintHand(round.DealerHand), cards.Evaluate(round.DealerHand))
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:  "Dealer",
+		Value: value,
+	}
+}
+
+// SeatFields Returns an embed field for each seat with all of the player's hands, marking whose turn it is
+func SeatFields(table *Table) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	round := table.Round
+
+	for index, seat := range round.Seats {
+		name := fmt.Sprintf("Seat %d - %s", index+1, table.Names[seat.PlayerID])
+		if player, ok := UserProfiles.Get(table.PlayerKey(seat.PlayerID)); ok {
+			name = fmt.Sprintf("Seat %d - %s %s", index+1, player.Rank.RankTitle, table.Names[seat.PlayerID])
+		}
+
+		switch {
+		case seat.Left:
+			name += " (left)"
+		case seat.Surrendered:
+			name += " (surrendered)"
+		case round.State == blackjack.PlayerTurn && index == round.Turn:
+			name += " (playing)"
+		}
+
+		var hands []string
+		for handIndex, hand := range seat.Hands {
+			line := fmt.Sprintf("%s (%v) - %d credits", cards.PrintHand(hand.Cards), hand.Result(), hand.Bet)
+			if len(seat.Hands) > 1 {
+				line = fmt.Sprintf("Hand %d: %s", handIndex+1, line)
+				if round.State == blackjack.PlayerTurn && index == round.Turn && handIndex == seat.Active {
+					line += " (playing)"
+				}
+			}
+			if hand.Doubled {
+				line += " (doubled)"
+			}
+			hands = append(hands, line)
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  name,
+			Value: strings.Join(hands, "\n"),
+		})
+	}
+
+	return fields
+}
+
+// RefreshTableEmbed Redraws the dealer, every seat, the shoe and the buttons on the table embed.
+// The message is edited in place, button presses were already acknowledged by ButtonHandler.
+func RefreshTableEmbed(session *discordgo.Session, table *Table) {
+	table.Embed.Fields = append(table.Embed.Fields[:1], DealerField(table.Round))
+	table.Embed.Fields = append(table.Embed.Fields, SeatFields(table)...)
+	table.Embed.Footer = &discordgo.MessageEmbedFooter{Text: ShoeStatus(table)}
+
+	_, err := session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         table.MessageID,
+		Channel:    table.ChannelID,
+		Embeds:     []*discordgo.MessageEmbed{table.Embed},
+		Components: TableButtons(table.Round),
+	})
+	if err != nil {
+		fmt.Println("Error updating table embed:", err)
+	}
+}
+
+// ReportEvents Sends a message for each step of the round the players need to see
+func ReportEvents(session *discordgo.Session, channelID string, table *Table, events []blackjack.Event) {
+	for _, event := range events {
+		var name string
+		var multipleHands bool
+		if event.Seat < len(table.Round.Seats) {
+			seat := table.Round.Seats[event.Seat]
+			name = table.Names[seat.PlayerID]
+			multipleHands = len(seat.Hands) > 1
+		}
+
+		switch event.Type {
+		case blackjack.InsuranceOffered:
+			OfferInsurance(session, channelID, table)
+		case blackjack.InsuranceSettled:
+			if event.Result.Payout > 0 {
+				session.ChannelMessageSend(channelID, fmt.Sprintf("%s: Insurance pays! You get %d credits back.", name, event.Result.Payout))
+			} else {
+				session.ChannelMessageSend(channelID, fmt.Sprintf("%s: Insurance lost. You lose %d credits.", name, event.Result.Bet))
+			}
+		case blackjack.SurrenderOffered:
+			session.ChannelMessageSend(channelID,
+				"Dealer shows a ten. Press Surrender to give up now for half your bet, or Decline to play on.")
+		case blackjack.DealerPeeked:
+			session.ChannelMessageSend(channelID, "Dealer checks for blackjack... no blackjack. Play on.")
+		case blackjack.HandBust:
+			if multipleHands {
+				session.ChannelMessageSend(channelID, fmt.Sprintf("%s: Hand %d BUSTS!", name, event.Hand+1))
+			}
+		case blackjack.NextHand:
+			session.ChannelMessageSend(channelID, fmt.Sprintf("%s: Playing hand %d...", name, event.Hand+1))
+		case blackjack.NextSeat:
+			session.ChannelMessageSend(channelID,
+				fmt.Sprintf("<@%s>, it's your turn (seat %d).", table.Round.Seats[event.Seat].PlayerID, event.Seat+1))
+		case blackjack.DealerPlays:
+			session.ChannelMessageSend(channelID, "Dealer's turn...")
+		case blackjack.DealerStood:
+			session.ChannelMessageSend(channelID, fmt.Sprintf("Dealer stands on %v...", cards.Evaluate(table.Round.DealerHand)))
+		case blackjack.HandSettled:
+			label := fmt.Sprintf("%s: ", name)
+			if multipleHands {
+				label = fmt.Sprintf("%s, hand %d: ", name, event.Hand+1)
+			}
+			ReportResult(session, channelID, label, event.Result)
+		case blackjack.RoundSettled:
+			RefreshTableEmbed(session, table)
+			SettleRound(session, channelID, table)
+		}
+	}
+}
+
+// OfferInsurance Asks the players to decide on insurance (or even money with a blackjack) when the dealer shows an ace
+func OfferInsurance(session *discordgo.Session, channelID string, table *Table) {
+	session.ChannelMessageSend(channelID,
+		fmt.Sprintf("Dealer shows an Ace. Press Insurance to insure for half your bet, type \"%s insure <amount>\" "+
+			"for a smaller bet, or Decline.", GuildPrefixes.Get(table.GuildID)))
+
+	var blackjacks []string
+	for _, seat := range table.Round.Seats {
+		if seat.HasBlackjack() {
+			blackjacks = append(blackjacks, table.Names[seat.PlayerID])
+		}
+	}
+	if len(blackjacks) > 0 {
+		session.ChannelMessageSend(channelID,
+			fmt.Sprintf("%s: you have a blackjack! Press Even Money to take it, or Decline.",
+				strings.Join(blackjacks, ", ")))
+	}
+
+	if table.Round.Rules.Surrender == blackjack.EarlySurrender {
+		session.ChannelMessageSend(channelID, "You can also surrender now for half your bet with Surrender.")
+	}
+}
+
+// ReportResult Announces how one of the player's hands ended, label names the player and hand
+func ReportResult(session *discordgo.Session, channelID string, label string, result blackjack.Result) {
+	switch result.Outcome {
+	case blackjack.PlayerBlackjack:
+		session.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
+			Title:       label + "Blackjack! You win!",
+			Description: fmt.Sprintf("You earned %d credits", result.Net()),
+			Color:       0,
+		})
+		return
+	case blackjack.DealerBlackjack:
+		session.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
+			Title:       label + "Dealer Blackjack! You lost!",
+			Description: fmt.Sprintf("You lost %d credits", result.Bet),
+			Color:       0,
+		})
+		return
+	case blackjack.PlayerBust:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sYou BUST! Dealer wins. You lost %d credits", label, result.Bet))
+		return
+	case blackjack.DealerBust:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sDealer BUST! Player wins! You get %d credits", label, result.Net()))
+		return
+	case blackjack.EvenMoney:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sEven money! You get %d credits", label, result.Net()))
+		return
+	case blackjack.Surrendered:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sYou surrender. %d credits of your bet are returned.", label, result.Payout))
+		return
+	case blackjack.Forfeited:
+		// Already announced when the player left
+		return
+	}
+
+	session.ChannelMessageSend(channelID, fmt.Sprintf("%sYour hand: %v\nDealer hand: %v\n",
+		label, result.PlayerTotal, result.DealerTotal))
+
+	switch result.Outcome {
+	case blackjack.Push:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sTie game! Push. Your bet of %d credits is returned.", label, result.Bet))
+	case blackjack.DealerWin:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sDealer Wins! You lost %d credits!", label, result.Bet))
+	case blackjack.PlayerWin:
+		session.ChannelMessageSend(channelID, fmt.Sprintf("%sPlayer Wins! You get %d credits!", label, result.Net()))
+	}
+}
+
+// SettleRound Pays out every seat at the table and ends the round.
+// The payouts and records of every seat are saved in one transaction.
+// END GAME
+func SettleRound(session *discordgo.Session, channelID string, table *Table) {
+	var changes []handler.PlayerChange
+	for _, seat := range table.Round.Seats {
+		key := table.PlayerKey(seat.PlayerID)
+
+		// The bets were already taken when the hands were dealt, split or doubled
+		change := handler.PlayerChange{GuildID: key.GuildID, UserID: key.UserID, Credits: seat.TotalPayout()}
+		for _, result := range seat.Results {
+			if result.Outcome.Won() {
+				change.Wins += 1
+			} else if result.Outcome.Lost() {
+				change.Losses += 1
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	// Seats that can't be paid right now keep their payout until it can be saved. It is only kept in memory,
+	// so say that a restart before then loses it.
+	if unsaved := UserProfiles.CommitEach(changes...); len(unsaved) > 0 {
+		var names []string
+		for _, change := range unsaved {
+			names = append(names, table.Names[change.UserID])
+		}
+		session.ChannelMessageSend(channelID, fmt.Sprintf("Couldn't save the results for %s yet. "+
+			"The bot keeps trying while it runs, but they are lost if it restarts before then.", strings.Join(names, ", ")))
+	}
+
+	table.Started = false
+	table.Round = nil
+
+	prefix := GuildPrefixes.Get(table.GuildID)
+	session.ChannelMessageSend(channelID, fmt.Sprintf("Round over. Type \"%s blackjack <amount>\" or \"%s table join <amount>\" "+
+		"to play the next round.", prefix, prefix))
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"discordgo-blackjack/blackjack"
+	"discordgo-blackjack/cards"
+	"discordgo-blackjack/profile"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordStub answers every Discord API request with an empty success, so handlers can run without a connection.
+// The requests are recorded as "METHOD path body".
+type discordStub struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (stub *discordStub) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.Body != nil {
+		body, _ = ioutil.ReadAll(request.Body)
+	}
+
+	stub.mu.Lock()
+	stub.requests = append(stub.requests, request.Method+" "+request.URL.Path+" "+string(body))
+	stub.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader("{}")),
+		Request:    request,
+	}, nil
+}
+
+// sent returns the recorded requests that contain every one of the parts
+func (stub *discordStub) sent(parts ...string) []string {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	var matching []string
+	for _, request := range stub.requests {
+		matches := true
+		for _, part := range parts {
+			matches = matches && strings.Contains(request, part)
+		}
+		if matches {
+			matching = append(matching, request)
+		}
+	}
+
+	return matching
+}
+
+// newTestSession returns a session whose API requests never leave the process, and the stub that answers them
+func newTestSession(t *testing.T) (*discordgo.Session, *discordStub) {
+	session, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("discordgo.New: %v", err)
+	}
+	stub := &discordStub{}
+	session.Client = &http.Client{Transport: stub}
+
+	return session, stub
+}
+
+// dealTestTable opens a table in its own channel and deals a round from a shoe with the given cards on top,
+// escrowing the bets like SitDown does. The players are seated in order, "player" if none are given.
+// Returns the table and the first player's profile key.
+func dealTestTable(t *testing.T, channelID string, codes string, bet int, players ...string) (*Table, ProfileKey) {
+	if len(players) == 0 {
+		players = []string{"player"}
+	}
+
+	top, err := cards.DecodeCards(codes)
+	if err != nil {
+		t.Fatalf("DecodeCards: %v", err)
+	}
+	shoe := cards.NewShoe(1, blackjack.DefaultPenetration, cards.NewSeededSource(1))
+	shoe.Deck.Cards = append(top, shoe.Deck.Cards...)
+
+	table, ok := Tables.Open(channelID, "guild", "player")
+	if !ok {
+		t.Fatalf("a table is already open in %s", channelID)
+	}
+	defer table.Unlock()
+	t.Cleanup(func() {
+		Tables.Close(table)
+	})
+
+	table.Shoe = shoe
+	table.Round = blackjack.NewRound(shoe, blackjack.DefaultTableRules())
+	for _, player := range players {
+		key := table.PlayerKey(player)
+		if err := UserProfiles.Load(key, player); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if err := EscrowCredits(key, bet); err != nil {
+			t.Fatalf("EscrowCredits: %v", err)
+		}
+
+		table.Names[player] = player
+		table.Keys[player] = key
+		if _, err := table.Round.Sit(player, bet); err != nil {
+			t.Fatalf("Sit: %v", err)
+		}
+	}
+	if _, err := table.Round.Deal(); err != nil {
+		t.Fatalf("Deal: %v", err)
+	}
+	table.Started = true
+	table.Embed = &discordgo.MessageEmbed{Fields: []*discordgo.MessageEmbedField{{Name: "How to play"}}}
+	Tables.Bind(table, channelID+"-embed")
+
+	return table, table.Keys[players[0]]
+}
+
+// press presses the button on the table embed as the user
+func press(session *discordgo.Session, table *Table, messageID string, userID string, customID string) {
+	InteractionHandler(session, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "press",
+		Token:     "token",
+		AppID:     "app",
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: table.ChannelID,
+		GuildID:   table.GuildID,
+		Message:   &discordgo.Message{ID: messageID},
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data:      discordgo.MessageComponentInteractionData{CustomID: customID},
+	}})
+}
+
+// pressConcurrently presses the button on the table embed from several goroutines at once
+func pressConcurrently(session *discordgo.Session, table *Table, customID string, presses int) {
+	messageID := table.MessageID
+
+	var wg sync.WaitGroup
+	for i := 0; i < presses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			press(session, table, messageID, "player", customID)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDoubleClickedHitDrawsOnce(t *testing.T) {
+	useMemoryStore(t)
+	session, _ := newTestSession(t)
+
+	// 16 against a nine, the king busts the hand and ends the round
+	table, _ := dealTestTable(t, "double-hit", "TH 9S 6C 8D KH 5S", 10)
+	hand := table.Round.Seats[0].Hands[0]
+	dealt := table.Shoe.Dealt
+
+	pressConcurrently(session, table, ButtonHit, 2)
+
+	table.Lock()
+	defer table.Unlock()
+
+	if drawn := table.Shoe.Dealt - dealt; drawn != 1 {
+		t.Fatalf("%d cards were drawn for a double clicked hit, want 1", drawn)
+	}
+	if codes := cards.EncodeCards(hand.Cards); codes != "TH 6C KH" {
+		t.Fatalf("hand is %s, want TH 6C KH", codes)
+	}
+	if table.Started || table.Round != nil {
+		t.Fatal("the round wasn't settled after the bust")
+	}
+}
+
+func TestDoubleClickedStandPaysOnce(t *testing.T) {
+	store := useMemoryStore(t)
+	session, _ := newTestSession(t)
+
+	// 20 against the dealer's 17, standing settles the round
+	table, key := dealTestTable(t, "double-stand", "TH 9S QC 8D", 10)
+
+	pressConcurrently(session, table, ButtonStand, 5)
+
+	table.Lock()
+	defer table.Unlock()
+
+	if table.Started || table.Round != nil {
+		t.Fatal("the round wasn't settled")
+	}
+
+	// The bet was taken once and the win paid once
+	want := profile.StartingCredits - 10 + 20
+	saved, _ := store.Get(key.GuildID, key.UserID)
+	cached, _ := UserProfiles.Get(key)
+	if saved.Credits != want || cached.Credits != want {
+		t.Fatalf("credits are %d saved and %d cached, want %d", saved.Credits, cached.Credits, want)
+	}
+	if saved.Wins != 1 || saved.Losses != 0 {
+		t.Fatalf("record is %d-%d, want 1-0", saved.Wins, saved.Losses)
+	}
+}
+
+func TestConcurrentTablesShareOneProfile(t *testing.T) {
+	store := useMemoryStore(t)
+	session, _ := newTestSession(t)
+
+	// The same player wins at two tables that settle at the same time
+	var tables []*Table
+	var key ProfileKey
+	for _, channelID := range []string{"first-table", "second-table"} {
+		var table *Table
+		table, key = dealTestTable(t, channelID, "TH 9S QC 8D", 10)
+		tables = append(tables, table)
+	}
+
+	var wg sync.WaitGroup
+	for _, table := range tables {
+		wg.Add(1)
+		go func(table *Table) {
+			defer wg.Done()
+			pressConcurrently(session, table, ButtonStand, 2)
+		}(table)
+	}
+	wg.Wait()
+
+	want := profile.StartingCredits + 2*(20-10)
+	saved, _ := store.Get(key.GuildID, key.UserID)
+	if saved.Credits != want || saved.Wins != 2 {
+		t.Fatalf("profile saved as %+v, want %d credits and 2 wins", saved, want)
+	}
+}
+
+func TestOutOfTurnPressIsAnswered(t *testing.T) {
+	useMemoryStore(t)
+	session, stub := newTestSession(t)
+
+	// first plays 17 and second 19 against the dealer's 17
+	table, _ := dealTestTable(t, "out-of-turn", "TH 9C 9S 7C QC 8D", 10, "first", "second")
+	dealt := table.Shoe.Dealt
+
+	press(session, table, table.MessageID, "second", ButtonHit)
+
+	table.Lock()
+	defer table.Unlock()
+
+	if table.Shoe.Dealt != dealt || table.Round.Turn != 0 {
+		t.Fatal("a press out of turn was played")
+	}
+	if followups := stub.sent("POST", "/webhooks/app/token", "It's first's turn."); len(followups) != 1 {
+		t.Fatalf("sent %d followups telling second whose turn it is, want 1", len(followups))
+	}
+}
@@ -4,6 +4,6 @@ module discordgo-blackjack
 go 1.16
 
 require (
-	github.com/bwmarrin/discordgo v0.23.2
+	github.com/bwmarrin/discordgo v0.27.1
 	github.com/lib/pq v1.10.3
 )
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrPlayerNotFound is returned when a user has no profile in the guild
+var ErrPlayerNotFound = errors.New("player not found")
+
+// ErrInsufficientCredits is returned when a credit adjustment would leave the player below zero
+var ErrInsufficientCredits = errors.New("not enough credits")
+
+// PlayerRecord is a player's saved profile in one guild
+type PlayerRecord struct {
+	UserID   string
+	GuildID  string
+	Username string
+	Credits  int
+	Wins     int
+	Losses   int
+	RankID   int
+}
+
+// PlayerChange is a change to one player's profile, made relative to the saved values
+type PlayerChange struct {
+	GuildID string
+	UserID  string
+	Credits int // Added to the credits (negative to take), fails with ErrInsufficientCredits below zero
+	Wins    int // Added to the wins
+	Losses  int // Added to the losses
+	RankID  int // New rank, 0 keeps the current one
+}
+
+// PlayerStore loads and saves player profiles
+type PlayerStore interface {
+	// Get returns the user's profile in the guild, ErrPlayerNotFound if they don't have one
+	Get(guildID string, userID string) (PlayerRecord, error)
+
+	// Create saves a new profile, a profile that already exists is left as it is
+	Create(player PlayerRecord) error
+
+	// Update saves the credits, record and rank of a profile, ErrPlayerNotFound if it doesn't exist
+	Update(player PlayerRecord) error
+
+	// ListByGuild returns every profile in the guild
+	ListByGuild(guildID string) ([]PlayerRecord, error)
+
+	// AdjustCredits adds amount (negative to take credits) and returns the new balance.
+	// Returns ErrInsufficientCredits without changing anything if the balance would go below zero.
+	AdjustCredits(guildID string, userID string, amount int) (int, error)
+
+	// ApplyChanges makes every change in one transaction and returns the updated profiles in the same order.
+	// If any change fails nothing is changed.
+	ApplyChanges(changes []PlayerChange) ([]PlayerRecord, error)
+}
+
+// PostgresPlayerStore keeps player profiles in the Player table (Postgres or CockroachDB)
+type PostgresPlayerStore struct {
+	db *sql.DB
+}
+
+// NewPostgresPlayerStore returns a player store using the database connection
+func NewPostgresPlayerStore(db *sql.DB) *PostgresPlayerStore {
+	return &PostgresPlayerStore{
+		db: db,
+	}
+}
+
+// Get returns the user's profile in the guild
+func (store *PostgresPlayerStore) Get(guildID string, userID string) (PlayerRecord, error) {
+	sqlGetPlayer := `SELECT user_id, guild_id, username, credits, wins, losses, user_rank
+		FROM Player WHERE guild_id=$1 AND user_id=$2`
+
+	var player PlayerRecord
+	err := store.db.QueryRow(sqlGetPlayer, guildID, userID).Scan(&player.UserID, &player.GuildID, &player.Username,
+		&player.Credits, &player.Wins, &player.Losses, &player.RankID)
+	if err == sql.ErrNoRows {
+		return PlayerRecord{}, ErrPlayerNotFound
+	}
+
+	return player, err
+}
+
+// Create saves a new profile
+func (store *PostgresPlayerStore) Create(player PlayerRecord) error {
+	sqlInsertNewPlayerProfile := `INSERT INTO Player
+		(user_id, guild_id, username, credits, wins, losses, user_rank)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING`
+
+	_, err := store.db.Exec(sqlInsertNewPlayerProfile, player.UserID, player.GuildID, player.Username,
+		player.Credits, player.Wins, player.Losses, player.RankID)
+	return err
+}
+
+// Update saves the credits, record and rank of a profile
+func (store *PostgresPlayerStore) Update(player PlayerRecord) error {
+	sqlSavePlayerData := `UPDATE Player SET credits=$3, wins=$4, losses=$5, user_rank=$6
+		WHERE user_id=$1 AND guild_id=$2`
+
+	result, err := store.db.Exec(sqlSavePlayerData, player.UserID, player.GuildID,
+		player.Credits, player.Wins, player.Losses, player.RankID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowUpdated(result)
+}
+
+// ListByGuild returns every profile in the guild
+func (store *PostgresPlayerStore) ListByGuild(guildID string) ([]PlayerRecord, error) {
+	sqlGetPlayerRecords := `SELECT user_id, guild_id, username, credits, wins, losses, user_rank
+		FROM Player WHERE guild_id=$1`
+
+	rows, err := store.db.Query(sqlGetPlayerRecords, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() // Rows is a pointer, needs to be closed
+
+	var players []PlayerRecord
+	for rows.Next() {
+		var player PlayerRecord
+		err = rows.Scan(&player.UserID, &player.GuildID, &player.Username,
+			&player.Credits, &player.Wins, &player.Losses, &player.RankID)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, player)
+	}
+
+	// get any error encountered during the iteration
+	return players, rows.Err()
+}
+
+// AdjustCredits adds amount to the player's credits in one statement, so concurrent adjustments can't overdraw
+func (store *PostgresPlayerStore) AdjustCredits(guildID string, userID string, amount int) (int, error) {
+	players, err := store.ApplyChanges([]PlayerChange{{GuildID: guildID, UserID: userID, Credits: amount}})
+	if err != nil {
+		return 0, err
+	}
+
+	return players[0].Credits, nil
+}
+
+// ApplyChanges makes every change in one transaction
+func (store *PostgresPlayerStore) ApplyChanges(changes []PlayerChange) ([]PlayerRecord, error) {
+	sqlApplyChange := `UPDATE Player SET credits=credits+$3, wins=wins+$4, losses=losses+$5,
+		user_rank=CASE WHEN $6 > 0 THEN $6 ELSE user_rank END
+		WHERE guild_id=$1 AND user_id=$2 AND credits+$3 >= 0
+		RETURNING user_id, guild_id, username, credits, wins, losses, user_rank`
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]PlayerRecord, len(changes))
+	for index, change := range changes {
+		player := &players[index]
+		err := tx.QueryRow(sqlApplyChange, change.GuildID, change.UserID, change.Credits, change.Wins, change.Losses,
+			change.RankID).Scan(&player.UserID, &player.GuildID, &player.Username,
+			&player.Credits, &player.Wins, &player.Losses, &player.RankID)
+
+		// No row changed: either the player doesn't exist or they can't cover the credits
+		if err == sql.ErrNoRows {
+			var exists bool
+			err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM Player WHERE guild_id=$1 AND user_id=$2)`,
+				change.GuildID, change.UserID).Scan(&exists)
+			if err == nil && exists {
+				err = ErrInsufficientCredits
+			} else if err == nil {
+				err = ErrPlayerNotFound
+			}
+		}
+
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return players, tx.Commit()
+}
+
+// checkRowUpdated returns ErrPlayerNotFound if the statement didn't change any row
+func checkRowUpdated(result sql.Result) error {
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrPlayerNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package handler
+
+import "database/sql"
+
+// LoadPrefix returns the command prefix saved for a guild. Returns false if the guild hasn't saved one.
+func (handler *BaseHandler) LoadPrefix(guildID string) (string, bool, error) {
+	var prefix string
+	err := handler.db.QueryRow(`SELECT prefix FROM GuildPrefixes WHERE guild_id=$1`, guildID).Scan(&prefix)
+
+	switch err {
+	case nil:
+		return prefix, true, nil
+	case sql.ErrNoRows:
+		return "", false, nil
+	}
+
+	return "", false, err
+}
+
+// SavePrefix inserts or replaces the command prefix for a guild
+func (handler *BaseHandler) SavePrefix(guildID string, prefix string) error {
+	sqlSavePrefix := `INSERT INTO GuildPrefixes (guild_id, prefix) VALUES ($1, $2)
+		ON CONFLICT (guild_id) DO UPDATE SET prefix=excluded.prefix`
+
+	_, err := handler.db.Exec(sqlSavePrefix, guildID, prefix)
+	return err
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"database/sql"
+	"discordgo-blackjack/blackjack"
+)
+
+// LoadTableRules returns the house rules saved for a guild. Returns false if the guild hasn't saved any.
+func (handler *BaseHandler) LoadTableRules(guildID string) (blackjack.TableRules, bool, error) {
+	sqlGetRules := `SELECT min_bet, max_bet, decks, penetration, hits_soft_17, blackjack_pays,
+		max_split_hands, double_on, double_after_split, surrender
+		FROM GuildSettings WHERE guild_id=$1`
+
+	var rules blackjack.TableRules
+	err := handler.db.QueryRow(sqlGetRules, guildID).Scan(&rules.MinBet, &rules.MaxBet, &rules.Decks,
+		&rules.Penetration, &rules.DealerHitsSoft17, &rules.BlackjackPays, &rules.MaxSplitHands,
+		&rules.DoubleOn, &rules.DoubleAfterSplit, &rules.Surrender)
+
+	switch err {
+	case nil:
+		return rules, true, nil
+	case sql.ErrNoRows:
+		return blackjack.DefaultTableRules(), false, nil
+	}
+
+	return blackjack.DefaultTableRules(), false, err
+}
+
+// SaveTableRules inserts or replaces the house rules for a guild
+func (handler *BaseHandler) SaveTableRules(guildID string, rules blackjack.TableRules) error {
+	sqlSaveRules := `INSERT INTO GuildSettings
+		(guild_id, min_bet, max_bet, decks, penetration, hits_soft_17, blackjack_pays,
+		max_split_hands, double_on, double_after_split, surrender)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (guild_id) DO UPDATE SET
+		min_bet=excluded.min_bet, max_bet=excluded.max_bet, decks=excluded.decks,
+		penetration=excluded.penetration, hits_soft_17=excluded.hits_soft_17,
+		blackjack_pays=excluded.blackjack_pays, max_split_hands=excluded.max_split_hands,
+		double_on=excluded.double_on, double_after_split=excluded.double_after_split,
+		surrender=excluded.surrender`
+
+	_, err := handler.db.Exec(sqlSaveRules, guildID, rules.MinBet, rules.MaxBet, rules.Decks,
+		rules.Penetration, rules.DealerHitsSoft17, rules.BlackjackPays, rules.MaxSplitHands,
+		rules.DoubleOn, rules.DoubleAfterSplit, rules.Surrender)
+	return err
+}
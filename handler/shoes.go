@@ -0,0 +1,52 @@
+package handler
+
+// ShoeRecord holds the seeds a shoe was shuffled with so players can verify it once it is finished
+type ShoeRecord struct {
+	ID             int64
+	GuildID        string
+	ChannelID      string
+	Decks          int
+	ServerSeedHash string
+	ServerSeed     string // Kept secret until the shoe is revealed
+	ClientSeed     string
+	Revealed       bool
+}
+
+// CreateShoe saves the seeds of a newly shuffled shoe and returns its id
+func (handler *BaseHandler) CreateShoe(shoe ShoeRecord) (int64, error) {
+	sqlCreateShoe := `INSERT INTO Shoes
+		(guild_id, channel_id, decks, server_seed_hash, server_seed, client_seed)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING shoe_id`
+
+	var shoeID int64
+	err := handler.db.QueryRow(sqlCreateShoe, shoe.GuildID, shoe.ChannelID, shoe.Decks,
+		shoe.ServerSeedHash, shoe.ServerSeed, shoe.ClientSeed).Scan(&shoeID)
+	return shoeID, err
+}
+
+// RevealShoe marks a finished shoe so its server seed can be shown
+func (handler *BaseHandler) RevealShoe(shoeID int64) error {
+	_, err := handler.db.Exec(`UPDATE Shoes SET revealed=true WHERE shoe_id=$1`, shoeID)
+	return err
+}
+
+// RevealUnfinishedShoes marks every shoe that is still being dealt as finished and returns how many there were
+func (handler *BaseHandler) RevealUnfinishedShoes() (int64, error) {
+	result, err := handler.db.Exec(`UPDATE Shoes SET revealed=true WHERE revealed=false`)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// LoadShoe returns the saved seeds of a shoe
+func (handler *BaseHandler) LoadShoe(shoeID int64) (ShoeRecord, error) {
+	sqlGetShoe := `SELECT shoe_id, guild_id, channel_id, decks, server_seed_hash, server_seed, client_seed, revealed
+		FROM Shoes WHERE shoe_id=$1`
+
+	var shoe ShoeRecord
+	err := handler.db.QueryRow(sqlGetShoe, shoeID).Scan(&shoe.ID, &shoe.GuildID, &shoe.ChannelID, &shoe.Decks,
+		&shoe.ServerSeedHash, &shoe.ServerSeed, &shoe.ClientSeed, &shoe.Revealed)
+	return shoe, err
+}
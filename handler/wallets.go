@@ -0,0 +1,23 @@
+package handler
+
+import "database/sql"
+
+// LoadSharedWallet returns true if the guild's players use their shared profile instead of one for the guild
+func (handler *BaseHandler) LoadSharedWallet(guildID string) (bool, error) {
+	var shared bool
+	err := handler.db.QueryRow(`SELECT shared FROM GuildWallets WHERE guild_id=$1`, guildID).Scan(&shared)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return shared, err
+}
+
+// SaveSharedWallet turns the shared wallet on or off for a guild
+func (handler *BaseHandler) SaveSharedWallet(guildID string, shared bool) error {
+	sqlSaveWallet := `INSERT INTO GuildWallets (guild_id, shared) VALUES ($1, $2)
+		ON CONFLICT (guild_id) DO UPDATE SET shared=excluded.shared`
+
+	_, err := handler.db.Exec(sqlSaveWallet, guildID, shared)
+	return err
+}
@@ -2,16 +2,14 @@ package main
 
 import (
 	"bufio"
-	"database/sql"
-	"discordgo-blackjack/cards"
 	"discordgo-blackjack/data"
 	"discordgo-blackjack/handler"
+	"discordgo-blackjack/profile"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
@@ -37,31 +35,39 @@ import (
   - Stand - finishes dealer turn
 */
 
-// EmojiList holds all the emoji icons for reactions
-var EmojiList map[string]string
+// Tables - Holds the blackjack table running in each channel
+var Tables = NewTableRegistry()
 
-// GameDeck - Holds info for game deck and player/dealer hands
-var GameDeck cards.Deck
-var PlayerHand []cards.Card
-var DealerHand []cards.Card
-var GameEmbed *discordgo.MessageEmbed
-
-var GameStarted bool = false
-
-// UserProfiles - map of user id to players
+// UserProfiles - map of (guild, user) to players
 // NOTE: this needs to be a pointer to structs so that values in map can be modified
-var UserProfiles = make(map[string]*cards.Player)
-
-var BetMultiplier int = 1
+var UserProfiles = NewProfileCache()
 
 var TokenFileName string = "./token.txt"
 
+// MembersIntentEnv turns on the privileged Server Members intent when set to "true". Enable the intent for the bot
+// in the Discord developer portal first, Discord refuses the connection otherwise.
+const MembersIntentEnv = "SERVER_MEMBERS_INTENT"
+
+// MembersIntent is true if the bot can list guild members and gets member join events
+var MembersIntent bool
+
 var DBController *handler.BaseHandler
 
+// Players - Loads and saves player profiles in the database
+var Players handler.PlayerStore
+
+// NoProfileMessage is the reply to users that don't have a player profile yet
+const NoProfileMessage = "You don't have a player profile yet."
+
 var BOTID string
 
 func main() {
 
+	// "migrate" manages the database schema instead of starting the bot
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(MigrateCommand(os.Args[2:]))
+	}
+
 	// Read token from file and close
 	file, err := os.Open(TokenFileName)
 	if err != nil {
@@ -78,8 +84,28 @@ func main() {
 	if err != nil {
 		log.Fatal("Error creating discord session")
 	}
-	// Need to add OnReadyHandler before opening connection, since it loads in the data
+	RegisterCommands()
+
+	// Load rank titles
+	profile.LoadRankTitles()
+
+	// The database has to be ready before the first guild is loaded
+	OpenDatabase()
+
+	// Listing members and member events need the privileged Server Members intent, so it is opt-in
+	bot.Identify.Intents = discordgo.IntentsAllWithoutPrivileged
+	MembersIntent, _ = strconv.ParseBool(os.Getenv(MembersIntentEnv))
+	if MembersIntent {
+		bot.Identify.Intents |= discordgo.IntentsGuildMembers
+	}
+
+	// Payouts that couldn't be saved when their round ended are retried while the bot runs
+	go RetryUnsavedChanges()
+
+	// Need to add the guild handlers before opening connection, since they load in the data
 	bot.AddHandler(OnReadyHandler)
+	bot.AddHandler(GuildCreateHandler)
+	bot.AddHandler(GuildMemberAddHandler)
 
 	// Open a connection
 	err = bot.Open()
@@ -90,7 +116,7 @@ func main() {
 	// NOTE: In discordgo, add handlers to listen for events such as creating a message, or on a reaction
 	//  -- similar to discord.py on_message, on_reaction_add
 	bot.AddHandler(CommandHandler)
-	bot.AddHandler(ReactionHandler)
+	bot.AddHandler(InteractionHandler)
 
 	// Wait until CTRL-C or process is interrupted to stop
 	fmt.Println("Bot is now running, press CTRL-C to exit.")
@@ -100,627 +126,288 @@ func main() {
 	signal.Notify(signalChannel, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-signalChannel
 
+	// Unsaved payouts only live in memory, log them so they can be applied by hand
+	for _, change := range UserProfiles.Unsaved() {
+		fmt.Printf("Exiting with an unsaved change for %s in %s: %+v\n", change.UserID, change.GuildID, change)
+	}
+
 	// Close connection
 	bot.Close()
 }
 
-// OnReadyHandler This is called when the bot is loaded up and connects
-// Used to preload bot data, etc
-func OnReadyHandler(session *discordgo.Session, rdy *discordgo.Ready) {
-
-	// Load rank titles
-	cards.LoadRankTitles()
-
-	// ============ Database connection ==============
+// OpenDatabase Connects to the database and brings the schema up to date before anything reads from it
+func OpenDatabase() {
 	db := data.OpenDBConnection()
 	DBController = handler.NewBaseHandler(db)
 
-	// create table works
-	sqlCreateTable := `CREATE TABLE IF NOT EXISTS Player(
-		user_id   varchar(20),
-		guild_id  varchar(20),
-		username  varchar(70),
-		credits   int,
-		wins      int,
-		losses    int,
-		user_rank int,
+	Players = handler.NewPostgresPlayerStore(db)
 
-		PRIMARY KEY(user_id, guild_id));
-	`
-
-	_, err := DBController.GetDBConn().Exec(sqlCreateTable) // returns (result, error)
+	applied, err := data.MigrateUp(db)
 	if err != nil {
-		fmt.Println("Error creating tables")
+		fmt.Println("Error migrating database")
 		panic(err)
 	}
+	for _, migration := range applied {
+		fmt.Printf("Applied migration %04d_%s\n", migration.Version, migration.Name)
+	}
 
-	LoadUserData(session, rdy, DBController.GetDBConn())
+	// Tables don't survive a restart, so no more cards are dealt from the shoes they were using.
+	// Reveal their server seeds so they can still be verified.
+	revealed, err := DBController.RevealUnfinishedShoes()
+	if err != nil {
+		fmt.Println("Error revealing unfinished shoes:", err)
+	} else if revealed > 0 {
+		fmt.Printf("Revealed %d shoes left unfinished by the last run\n", revealed)
+	}
 }
 
-// Loads user data when bot starts up
-func LoadUserData(session *discordgo.Session, rdy *discordgo.Ready, db *sql.DB) {
-
-	// Global emoji list
-	EmojiList = make(map[string]string)
-
-	// NOTE: Use session to get the current guild, rdy guild[0] is missing info
-	for _, gd := range session.State.Guilds {
-
-		sqlCheckDataExists := `SELECT COUNT(*) FROM Player WHERE guild_id=$1`
-		var membersLoaded int
-
-		// Query for row in table, then scan into selected variables
-		row := db.QueryRow(sqlCheckDataExists, gd.ID)
-
-		switch err := row.Scan(&membersLoaded); err {
-		case sql.ErrNoRows:
-			log.Println("No rows returned")
-		case nil:
-
-			// If no members from guild is loaded (if 0 -> then create new profiles for each user)
-			if membersLoaded == 0 {
-				// Leave the after argument as empty to get all guild members
-				members, _ := session.GuildMembers(gd.ID, "", 10)
-
-				sqlInsertNewPlayerProfile := `INSERT INTO Player
-					(user_id, guild_id, username, credits, wins, losses, user_rank)
-					VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING`
-
-				// Load all members into a map for quick access
-				for _, member := range members {
-					UserProfiles[member.User.ID] = &cards.Player{
-						Name:    member.User.Username,
-						GuildID: gd.ID,
-						Credits: 1000,
-						Wins:    0,
-						Losses:  0,
-						Rank:    cards.RankMap[11],
-					}
-
-					// Insert new player data into the database
-					_, err := DBController.GetDBConn().Exec(sqlInsertNewPlayerProfile, member.User.ID, gd.ID, member.User.Username,
-						1000, 0, 0, 11)
-					if err != nil {
-						panic(err)
-					}
-				}
-				fmt.Println("New player data created")
-
-			} else {
-				// TODO: Query for row of users from db and load them into the map
-				sqlGetPlayerRecords := `SELECT user_id, guild_id, username, credits, wins, losses, user_rank 
-					FROM Player WHERE guild_id=$1`
-				rows, err := db.Query(sqlGetPlayerRecords, gd.ID)
-				if err != nil {
-					panic(err)
-				}
-				defer rows.Close() // Rows is a pointer, needs to be closed
-
-				// Go through each row and create player
-				for rows.Next() {
-					var userid string
-					var gid string
-					var username string
-					var credits int
-					var wins int
-					var losses int
-					var rank int
-					err = rows.Scan(&userid, &gid, &username, &credits, &wins, &losses, &rank)
-					if err != nil {
-						panic(err)
-					}
-					UserProfiles[userid] = &cards.Player{
-						Name:    username,
-						GuildID: gid,
-						Credits: credits,
-						Wins:    wins,
-						Losses:  losses,
-						Rank:    cards.RankMap[rank],
-					}
-				}
-
-				// get any error encountered during the iteration
-				err = rows.Err()
-				if err != nil {
-					panic(err)
-				}
-
-				fmt.Println("Existing player data loaded")
-
-			}
+// UnsavedRetryInterval is how often payouts that couldn't be saved are tried again
+const UnsavedRetryInterval = time.Minute
 
-		default:
-			panic(err)
+// RetryUnsavedChanges Tries to save the payouts that couldn't be saved, for as long as the bot runs
+func RetryUnsavedChanges() {
+	for range time.Tick(UnsavedRetryInterval) {
+		if left := UserProfiles.RetryUnsaved(); left > 0 {
+			fmt.Println(left, "changes are still waiting to be saved")
 		}
-
-		// Load emoji data
-		guild, _ := session.Guild(gd.ID)
-		for _, emoji := range guild.Emojis {
-			if _, ok := EmojiList[emoji.Name]; ok {
-				fmt.Println("Emoji name already exists in map")
-			} else {
-				// NOTE: NEED to use APINAME() to pass to MessageReactionAdd for the emojiID (discord format is emojiName:emojiID)
-				EmojiList[emoji.Name] = emoji.APIName()
-			}
-		}
-
-		// NOTE: Manually loading in emojis to debug issue with unresponsive reactions
-		EmojiList["CHECKBOX_APPROVE"] = "✅"
-		EmojiList["CHECKBOX_DECLINE"] = "❌"
-		EmojiList["TAP_HIT"] = "👆"
-		EmojiList["TAP_STAND"] = "✋"
-		EmojiList["STOP"] = "🛑"
-
 	}
-	fmt.Println("Member data loaded successfully")
-	fmt.Println("Emoji Data loaded successfully")
-
 }
 
-// CommandHandler Handles commands when user types in a message
-func CommandHandler(session *discordgo.Session, msg *discordgo.MessageCreate) {
-	// Ignore self-messages from bot
-	if msg.Author.ID == session.State.User.ID {
-		return
-	}
+// OnReadyHandler This is called when the bot is loaded up and connects
+// Each guild is loaded when its GuildCreate event arrives, which Discord sends right after this one
+func OnReadyHandler(session *discordgo.Session, rdy *discordgo.Ready) {
+	fmt.Printf("Connected as %s, loading %d guilds\n", rdy.User.Username, len(rdy.Guilds))
+}
 
-	// Set prefix as !game for commands
-	if !strings.HasPrefix(msg.Content, "!game") {
+// GuildCreateHandler Loads a guild's data when the bot connects, joins the guild, or the guild comes back from an outage
+func GuildCreateHandler(session *discordgo.Session, guild *discordgo.GuildCreate) {
+	if guild.Unavailable {
 		return
 	}
 
-	// Get arguments from message
-	args := strings.Split(msg.Content, " ")
-	args = args[1:]
+	LoadGuildData(session, guild.ID)
+	fmt.Println("Guild data loaded for", guild.Name)
+}
 
-	// Make sure command has a valid argument
-	if len(args) < 1 {
-		log.Println("Make sure to enter a command. Type !game help to see a list of commands.")
+// GuildMemberAddHandler Creates a profile for members as they join, so they can play right away (only sent with MembersIntent)
+func GuildMemberAddHandler(session *discordgo.Session, member *discordgo.GuildMemberAdd) {
+	if member.User.Bot {
 		return
 	}
 
-	// First argument should be the command name
-	commandName := args[0]
-
-	// If argument is length 2, grab the 2nd argument as shop option
-	var shopChoice int
-	if len(args) == 2 {
-		shopChoice, _ = strconv.Atoi(args[1])
+	key := ProfileKeyOf(member.GuildID, member.User.ID)
+	if err := UserProfiles.Load(key, member.User.Username); err != nil {
+		fmt.Println("Error creating profile for", member.User.ID, "in guild", member.GuildID, err)
 	}
+}
 
-	// Start game, show help, etc
-	switch commandName {
-	case "blackjack":
-
-		// Set initial bet multiplier to 1
-		BetMultiplier = 1.0
-
-		// Initialize card deck
-		GameDeck.CreateDeck(6)
-		GameDeck.Reshuffle()
-		GameStarted = true
-
-		// Deal starting cards
-		PlayerHand, DealerHand = cards.DealStartingHand(&GameDeck)
-
-		session.ChannelMessageSend(msg.ChannelID, "Welcome to Blackjack. Dealing out initial hands.")
-
-		GameEmbed = &discordgo.MessageEmbed{
-			Title: "Blackjack Table",
-			Description: fmt.Sprintf("Two initial cards have been dealt to the dealer and player. Click %s to double bet, or %s to continue",
-				cards.CHECKBOX_APPROVE, cards.CHECKBOX_DECLINE),
-			Color:  0,
-			Footer: nil,
-			Fields: []*discordgo.MessageEmbedField{
-				{
-					Name: fmt.Sprintf("%s %s", UserProfiles[msg.Author.ID].Rank.RankTitle, msg.Author.Username),
-					Value: fmt.Sprintf("User %v's turn. React with %s to hit, %s to stand, or %s tp quit",
-						msg.Author.Username, cards.TAP_HIT, cards.TAP_STAND, cards.STOP_SIGN_EMOJI),
-				},
-				{
-					Name:  "Your current Hand",
-					Value: fmt.Sprintf("%v\n", cards.PrintHand(PlayerHand)),
-				},
-			},
-		}
+// LoadGuildData Loads a guild's settings and player profiles and registers its slash commands
+func LoadGuildData(session *discordgo.Session, guildID string) {
 
-		embed, _ := session.ChannelMessageSendEmbed(msg.ChannelID, GameEmbed)
-
-		// Wait for user to double bet or continue
-		// TODO: Currently bugged for wait on reaction
-		session.MessageReactionAdd(msg.ChannelID, embed.ID, EmojiList["CHECKBOX_APPROVE"])
-		session.MessageReactionAdd(msg.ChannelID, embed.ID, EmojiList["CHECKBOX_DECLINE"])
-		// _ = <-waitForReaction(session)
-
-		time.AfterFunc(time.Second*3, func() {
-
-			// NOTE: This is the only message remove function that I found that will work for the moment (RemoveAll doesn't work)
-			session.MessageReactionRemove(msg.ChannelID, embed.ID, "✅", session.State.User.ID)
-			session.MessageReactionRemove(msg.ChannelID, embed.ID, "❌", session.State.User.ID)
-
-			session.MessageReactionAdd(msg.ChannelID, embed.ID, cards.TAP_HIT)
-			session.MessageReactionAdd(msg.ChannelID, embed.ID, cards.TAP_STAND)
-			session.MessageReactionAdd(msg.ChannelID, embed.ID, cards.STOP_SIGN_EMOJI)
-		})
-
-		// If player gets an immediate blackjack then end game
-		if cards.IsBlackjack(PlayerHand) {
-			session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
-				Title:       "Blackjack! You win!",
-				Description: "You earned 750 credits",
-				Color:       0,
-			})
-			GameStarted = false
-
-			// Add 750 credits to player for a blackjack
-			// END GAME
-			if player, ok := UserProfiles[msg.Author.ID]; ok {
-				player.Credits += 750
-				player.Wins += 1
-			}
-
-			return
-		} else if cards.IsBlackjack(DealerHand) {
-			session.ChannelMessageSendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
-				Title:       "Dealer Blackjack! You lost!",
-				Description: "You lost 750 credits",
-				Color:       0,
-			})
-			GameStarted = false
-
-			// Deduct 750 credits from player if they lose
-			if player, ok := UserProfiles[msg.Author.ID]; ok {
-				player.Credits -= 750
-				player.Losses += 1
-
-				// Make sure you can't have negative credits
-				if player.Credits < 0 {
-					player.Credits = 0
-				}
-			}
+	// Load whether the guild uses the shared wallet before its profiles, so the right ones are loaded
+	shared, err := DBController.LoadSharedWallet(guildID)
+	if err != nil {
+		fmt.Println("Error loading wallet setting for guild", guildID, err)
+	}
+	SharedWallets.Set(guildID, shared)
 
-			return
-		}
+	if err := LoadGuildPlayers(session, guildID); err != nil {
+		fmt.Println("Error loading player data for guild", guildID, err)
+	}
 
-		// TODO: the rest of game logic is in CommandHandler when the player reacts to the given table reactions
-
-	case "wallet":
-		DisplayPlayerCredits(session, msg)
-	case "save":
-		SavePlayerData(session, msg, data.DBConn)
-	case "stats":
-		DisplayPlayerStats(session, msg)
-	case "shop":
-		DisplayGameShop(session, msg)
-	case "ranks":
-		DisplayRanks(session, msg)
-	case "buy":
-		PurchaseRankTitle(session, msg, shopChoice)
-
-	case "help":
-		helpEmbed := &discordgo.MessageEmbed{
-			URL:         "",
-			Type:        "",
-			Title:       "List of Commands",
-			Description: "List of Commands available",
-			Timestamp:   "",
-			Color:       0,
-			Footer:      nil,
-			Image:       nil,
-			Thumbnail:   nil,
-			Video:       nil,
-			Provider:    nil,
-			Author:      nil,
-			Fields: []*discordgo.MessageEmbedField{
-				{
-					Name:  "!game help",
-					Value: "Display a list of commands",
-				},
-				{
-					Name:  "!game blackjack",
-					Value: "Starts a game of blackjack with the CPU",
-				},
-				{
-					Name:  "!game wallet",
-					Value: "Shows how many credits you have.",
-				},
-				{
-					Name:  "!game stats",
-					Value: "Displays your win-loss record and rank",
-				},
-				{
-					Name:  "!game shop",
-					Value: "Displays a list of titles you can purchase",
-				},
-			},
-		}
-		_, err := session.ChannelMessageSendEmbed(msg.ChannelID, helpEmbed)
-		if err != nil {
-			fmt.Println("Error showing help")
-			return
-		}
+	// Load the guild's house rules (guilds that never changed them play with the defaults)
+	rules, found, err := DBController.LoadTableRules(guildID)
+	if err != nil {
+		fmt.Println("Error loading house rules for guild", guildID, err)
+	} else if found {
+		GuildRules.Set(guildID, rules)
+	}
 
-	default:
-		session.ChannelMessageSend(msg.ChannelID, "Type \"!game help\" for a list of game commands")
+	// Load the guild's command prefix (guilds that never changed it use !game)
+	prefix, found, err := DBController.LoadPrefix(guildID)
+	if err != nil {
+		fmt.Println("Error loading command prefix for guild", guildID, err)
+	} else if found {
+		GuildPrefixes.Set(guildID, prefix)
 	}
+
+	// Slash commands are registered per guild so changes show up right away
+	RegisterSlashCommands(session, guildID)
 }
 
-// ReactionHandler Handles events when a user reacts to a message
-func ReactionHandler(session *discordgo.Session, reaction *discordgo.MessageReactionAdd) {
+// MemberPageSize is the most members Discord returns per request
+const MemberPageSize = 1000
 
-	// Make sure bot doesn't respond to its own reaction
-	if reaction.UserID == BOTID {
-		return
+// LoadGuildPlayers Loads the profiles the guild's players use into the cache, and creates profiles for members that don't
+// have one yet. Profiles that are already cached are kept, they were saved with every change so they match the database.
+// Without the Server Members intent the members can't be listed, they get a profile on their first command instead.
+func LoadGuildPlayers(session *discordgo.Session, guildID string) error {
+	wallet := SharedWallets.WalletOf(guildID)
+	players, err := Players.ListByGuild(wallet)
+	if err != nil {
+		return err
 	}
 
-	// In cases with multiple discord bots, ignore reactions on messages that the bot doesn't send out
-	channelMsg, _ := session.ChannelMessage(reaction.ChannelID, reaction.MessageID)
-	if channelMsg.Author.ID != BOTID {
-		return
+	// Existing player data
+	hasProfile := make(map[string]bool)
+	for _, player := range players {
+		UserProfiles.Add(ProfileKey{GuildID: wallet, UserID: player.UserID}, PlayerFromRecord(player))
+		hasProfile[player.UserID] = true
 	}
 
-	if !GameStarted {
-		return
+	if !MembersIntent {
+		fmt.Printf("Loaded %d profiles for guild %s\n", len(players), guildID)
+		return nil
 	}
 
-	switch reaction.Emoji.Name {
-	case "👆":
-		HitReactionHandler(session, reaction)
-
-		// NOTE: Need to find a way to remove user's reaction once clicked on
-		// session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, EmojiList["TAP_HIT"], session.State.User.ID)
-
-	case "✋":
-		session.ChannelMessageSend(reaction.ChannelID, "You stand. Dealer's turn...")
-		// session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, "\U0000270B", session.State.User.ID)
-
-		// Dealer's turn
-		for {
-			dealerHandValue := cards.HandValue(DealerHand)
-			fmt.Println("Dealer hand value:", dealerHandValue)
-
-			// ======= Player wins, dealer busts ============
-			if dealerHandValue > 21 {
-				session.ChannelMessageSend(reaction.ChannelID, fmt.Sprintf("Dealer BUST! Player wins! You get %d credits", 300*BetMultiplier))
-				GameStarted = false
-
-				// Add 300 credits to player if they win
-				// END GAME
-				if player, ok := UserProfiles[reaction.UserID]; ok {
-					player.Credits += 300 * BetMultiplier
-					player.Wins += 1
-				}
-
-				return
-			} else if dealerHandValue >= 17 { // Stands at soft 17 (any hand with an ace in it -> A-6, A-3-3, A-4-2)
-				session.ChannelMessageSend(reaction.ChannelID, "Dealer stands...")
-				GameStarted = false
-				break // Make sure to break so dealer doesn't draw another card after standing
-			}
-
-			// Draw another card and add to hand if dealer hasn't reached 17
-			drawnCard := GameDeck.DrawCard()
-			DealerHand = append(DealerHand, drawnCard)
+	// Page through the members by ID, each page starts after the last member of the one before
+	created := 0
+	after := ""
+	for {
+		members, err := session.GuildMembers(guildID, after, MemberPageSize)
+		if err != nil {
+			return err
 		}
 
-		// Check game win scenarios
-		// Player busts -> dealer automatically wins
-		// Player doesn't bust, dealer does -> player wins
-		// Neither bust -> hand value is compared
-		// Tie game
-
-		session.ChannelMessageSend(reaction.ChannelID, fmt.Sprintf("Your hand: %v\nDealer hand: %v\n",
-			cards.HandValue(PlayerHand), cards.HandValue(DealerHand)))
-
-		if cards.HandValue(PlayerHand) == cards.HandValue(DealerHand) {
-			session.ChannelMessageSend(reaction.ChannelID, "Tie game! Push.")
-			GameStarted = false
-		} else if cards.HandValue(PlayerHand) < cards.HandValue(DealerHand) {
-
-			fmt.Println("Player Hand: ", cards.PrintHand(PlayerHand), cards.HandValue(PlayerHand))
-			fmt.Println("Dealer Hand: ", cards.PrintHand(DealerHand), cards.HandValue(DealerHand))
-			session.ChannelMessageSend(reaction.ChannelID, fmt.Sprintf("Dealer Wins! You lost %d credits!", 300*BetMultiplier))
-
-			// END GAME
-			if player, ok := UserProfiles[reaction.UserID]; ok {
-				player.Credits -= 300 * BetMultiplier
-				player.Losses += 1
-
-				// Make sure you can't have negative credits
-				if player.Credits < 0 {
-					player.Credits = 0
-				}
+		for _, member := range members {
+			if member.User.Bot || hasProfile[member.User.ID] {
+				continue
 			}
 
-			GameStarted = false
-
-		} else {
-			// END GAME
-			session.ChannelMessageSend(reaction.ChannelID, fmt.Sprintf("Player Wins! You get %d credits!", 300*BetMultiplier))
-
-			if player, ok := UserProfiles[reaction.UserID]; ok {
-				player.Credits += 300 * BetMultiplier
-				player.Wins += 1
+			if err := UserProfiles.Load(ProfileKey{GuildID: wallet, UserID: member.User.ID}, member.User.Username); err != nil {
+				return err
 			}
-
-			GameStarted = false
+			created++
 		}
 
-	case "✅":
-		session.ChannelMessageSend(reaction.ChannelID, "Doubling rewards/losses -- 2x Credits")
-		// session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, "✅", currUser.ID)
-		BetMultiplier = 2
-		GameStarted = true
-	case "❌":
-		session.ChannelMessageSend(reaction.ChannelID, "Continue with game...")
-		// session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, "❌", currUser.ID)
-		GameStarted = true
-	case "eight":
-		log.Println("Start game with 4 decks")
-
-		//For unicode emojis, just place the actual emoji for the name
-	case "🛑":
-		session.ChannelMessageSend(reaction.ChannelID, "Quitting game.")
-		GameStarted = false
+		if len(members) < MemberPageSize {
+			break
+		}
+		after = members[len(members)-1].User.ID
 	}
-}
+	fmt.Printf("Loaded %d profiles and created %d for guild %s\n", len(players), created, guildID)
 
-// Waits for a reaction and adds a handler to the current session. Returns a channel with the reaction in it.
-// func waitForReaction(session *discordgo.Session) chan *discordgo.MessageReactionAdd {
-// 	channel := make(chan *discordgo.MessageReactionAdd)
-// 	session.AddHandlerOnce(func(_ *discordgo.Session, rxn *discordgo.MessageReactionAdd) {
-// 		channel <- rxn
-// 	})
-// 	return channel
-// }
-
-// HitReactionHandler Handles logic when players hit for another card
-func HitReactionHandler(session *discordgo.Session, reaction *discordgo.MessageReactionAdd) {
-
-	drawnCard := GameDeck.DrawCard()
-	PlayerHand = append(PlayerHand, drawnCard)
-
-	// Update player hand and embed
-	GameEmbed.Fields[1].Value = fmt.Sprintf("%v", cards.PrintHand(PlayerHand))
-	session.ChannelMessageEditEmbed(reaction.ChannelID, reaction.MessageID, GameEmbed)
-
-	// Check if player has busted
-	// END GAME
-	if cards.IsBust(PlayerHand) {
-		session.ChannelMessageSend(reaction.ChannelID, "You BUST! Dealer wins")
-		GameStarted = false
-
-		if user, ok := UserProfiles[reaction.UserID]; ok {
-			user.Credits -= 300 * BetMultiplier
-
-			// Make sure you can't have negative credits
-			if user.Credits < 0 {
-				user.Credits = 0
-			}
-		}
+	return nil
+}
 
+// EnsurePlayerProfile Creates a profile for the user the first time they use a command in the guild
+func EnsurePlayerProfile(ctx *CommandContext) {
+	if ctx.GuildID == "" {
 		return
 	}
-}
-
-// SavePlayerData Saves a user profile - updates in database
-func SavePlayerData(session *discordgo.Session, msg *discordgo.MessageCreate, db *sql.DB) {
-	currentPlayerID := msg.Author.ID
 
-	// If player is found in profiles, update the current status
-	if currentPlayer, ok := UserProfiles[currentPlayerID]; ok {
-
-		sqlSavePlayerData := `UPDATE Player SET credits=$2, wins=$3, losses=$4, user_rank=$5 WHERE user_id=$1;`
-		_, err := DBController.GetDBConn().Exec(sqlSavePlayerData, currentPlayerID, currentPlayer.Credits,
-			currentPlayer.Wins, currentPlayer.Losses, currentPlayer.Rank.RankID)
-		if err != nil {
-			panic(err)
-		}
-
-		session.ChannelMessageSend(msg.ChannelID, "Your data has been saved.")
+	if err := UserProfiles.Load(ctx.ProfileKey(), ctx.Username); err != nil {
+		fmt.Println("Error loading profile for", ctx.UserID, "in guild", ctx.GuildID, err)
 	}
+}
 
+// SavePlayerData Profiles are saved after every change, so this only tells the player their data is safe
+func SavePlayerData(ctx *CommandContext) {
+	ctx.ReplyPrivate("Your data is saved automatically after every bet, round and purchase.")
 }
 
 // Display player stats
-func DisplayPlayerStats(session *discordgo.Session, msg *discordgo.MessageCreate) {
+func DisplayPlayerStats(ctx *CommandContext) {
 
-	playerID := msg.Author.ID
+	player, ok := UserProfiles.Get(ctx.ProfileKey())
+	if !ok {
+		ctx.ReplyPrivate(NoProfileMessage)
+		return
+	}
 	statsEmbed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("Stats for %v", msg.Author.Username),
+		Title:       fmt.Sprintf("Stats for %v", ctx.Username),
 		Description: "Blackjack Records",
 		Color:       0,
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:  "Wins",
-				Value: strconv.Itoa(UserProfiles[playerID].Wins),
+				Value: strconv.Itoa(player.Wins),
 			},
 			{
 				Name:  "Losses",
-				Value: strconv.Itoa(UserProfiles[playerID].Losses),
+				Value: strconv.Itoa(player.Losses),
 			},
 			{
 				Name:  "Rank",
-				Value: UserProfiles[playerID].Rank.RankTitle,
+				Value: player.Rank.RankTitle,
 			},
 		},
 	}
 
-	_, err := session.ChannelMessageSendEmbed(msg.ChannelID, statsEmbed)
-	if err != nil {
-		fmt.Println("Error showing stats embed")
-		return
-	}
+	ctx.ReplyPrivateEmbed(statsEmbed)
 }
 
 // Display player credits
-func DisplayPlayerCredits(session *discordgo.Session, msg *discordgo.MessageCreate) {
+func DisplayPlayerCredits(ctx *CommandContext) {
 
-	playerID := msg.Author.ID
+	player, ok := UserProfiles.Get(ctx.ProfileKey())
+	if !ok {
+		ctx.ReplyPrivate(NoProfileMessage)
+		return
+	}
 	creditsEmbed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("Stats for %v", msg.Author.Username),
+		Title:       fmt.Sprintf("Stats for %v", ctx.Username),
 		Description: "Wallet",
 		Color:       0,
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:  "Credits",
-				Value: strconv.Itoa(UserProfiles[playerID].Credits),
+				Value: strconv.Itoa(player.Credits),
 			},
 		},
 	}
 
-	_, err := session.ChannelMessageSendEmbed(msg.ChannelID, creditsEmbed)
-	if err != nil {
-		fmt.Println("Error showing creditsEmbed")
-		return
-	}
+	ctx.ReplyPrivateEmbed(creditsEmbed)
 }
 
 // Display game shop
-func DisplayGameShop(session *discordgo.Session, msg *discordgo.MessageCreate) {
-	playerID := msg.Author.ID
+func DisplayGameShop(ctx *CommandContext) {
+	player, ok := UserProfiles.Get(ctx.ProfileKey())
+	if !ok {
+		ctx.ReplyPrivate(NoProfileMessage)
+		return
+	}
+
 	shopEmbed := &discordgo.MessageEmbed{
 		Title: "Blackjack Bazaar",
-		Description: fmt.Sprintf("Type \"!game buy <X> for the corresponding title to purchase.\n"+
-			" e.g !game buy 4 to purchase Title 4\n"+
-			"**Your Wallet Total - %s credits**", strconv.Itoa(UserProfiles[playerID].Credits)),
+		Description: fmt.Sprintf("Type \"%s buy <X>\" or /buy for the corresponding title to purchase.\n"+
+			" e.g %s buy 4 to purchase Title 4\n"+
+			"**Your Wallet Total - %s credits**", ctx.Prefix(), ctx.Prefix(), strconv.Itoa(player.Credits)),
 		Color:  0,
-		Fields: cards.RanksMessageEmbedField(),
+		Fields: profile.RanksMessageEmbedField(),
 	}
 
-	_, err := session.ChannelMessageSendEmbed(msg.ChannelID, shopEmbed)
-	if err != nil {
-		fmt.Println("Error showing creditsEmbed")
-		return
-	}
+	ctx.ReplyPrivateEmbed(shopEmbed)
 }
 
 // DisplayRanks Display all ranks
-func DisplayRanks(session *discordgo.Session, msg *discordgo.MessageCreate) {
+func DisplayRanks(ctx *CommandContext) {
 	rankEmbed := &discordgo.MessageEmbed{
 		Title:       "List of Ranks",
-		Description: cards.PrintRanksInOrder(),
+		Description: profile.PrintRanksInOrder(),
 		Color:       0,
 	}
 
-	_, err := session.ChannelMessageSendEmbed(msg.ChannelID, rankEmbed)
-	if err != nil {
-		fmt.Println("Error showing creditsEmbed")
-		return
-	}
+	ctx.ReplyEmbed(rankEmbed)
 }
 
-// PurchaseRankTitle
-func PurchaseRankTitle(session *discordgo.Session, msg *discordgo.MessageCreate, shopChoice int) {
-
-	if player, ok := UserProfiles[msg.Author.ID]; ok {
-		if cards.CanBuyNextRank(session, msg, player, shopChoice) {
-			player.Credits -= cards.RankMap[shopChoice].RankCost
-			player.Rank = cards.RankMap[shopChoice]
-			session.ChannelMessageSend(msg.ChannelID,
-				fmt.Sprintf("You have purchased the next rank: %s", player.Rank.RankTitle))
+// PurchaseRankTitle Buys the next rank title, taking its cost and saving the new rank in one transaction
+func PurchaseRankTitle(ctx *CommandContext, shopChoice int) {
 
-			SavePlayerData(session, msg, data.DBConn)
+	// Check and take the credits in one step so two purchases can't spend the same credits
+	err := UserProfiles.Change(ctx.ProfileKey(), func(player profile.Player) (handler.PlayerChange, error) {
+		if err := profile.CanBuyNextRank(&player, shopChoice); err != nil {
+			return handler.PlayerChange{}, err
 		}
+		return handler.PlayerChange{Credits: -profile.RankMap[shopChoice].RankCost, RankID: shopChoice}, nil
+	})
+
+	switch err {
+	case nil:
+		ctx.Reply(fmt.Sprintf("You have purchased the next rank: %s", profile.RankMap[shopChoice].RankTitle))
+	case profile.ErrUnknownRank, profile.ErrRankOwned, profile.ErrNotEnoughCredits, profile.ErrRankTooHigh:
+		ctx.ReplyPrivate(fmt.Sprintf("Can't buy that rank: %v.", err))
+	default:
+		ctx.ReplyPrivate(CreditErrorMessage(err, "You don't have enough credits to purchase this rank."))
 	}
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"discordgo-blackjack/data"
+	"fmt"
+	"strconv"
+)
+
+// MigrateCommand Runs "discordgo-blackjack migrate <status|up|down [steps]>" against the database and
+// returns the exit code. The bot isn't started.
+func MigrateCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: discordgo-blackjack migrate <status|up|down [steps]>")
+		return 2
+	}
+
+	db := data.OpenDBConnection()
+	defer db.Close()
+
+	switch args[0] {
+	case "status":
+		statuses, err := data.MigrationStatuses(db)
+		if err != nil {
+			fmt.Println("Error reading migrations:", err)
+			return 1
+		}
+
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s: %s\n", status.Version, status.Name, applied)
+		}
+
+	case "up":
+		applied, err := data.MigrateUp(db)
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Println("Error applying migrations:", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("The database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fmt.Println("Usage: discordgo-blackjack migrate down [steps]")
+				return 2
+			}
+		}
+
+		reverted, err := data.MigrateDown(db, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Println("Error reverting migrations:", err)
+			return 1
+		}
+
+	default:
+		fmt.Println("Usage: discordgo-blackjack migrate <status|up|down [steps]>")
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultPrefix is the command prefix of guilds that haven't chosen their own
+const DefaultPrefix = "!game"
+
+// MaxPrefixLength is the longest command prefix a guild can choose
+const MaxPrefixLength = 20
+
+// GuildPrefixes - Holds the command prefix each guild uses
+var GuildPrefixes = NewPrefixRegistry()
+
+// PrefixRegistry keeps the command prefix for each guild
+type PrefixRegistry struct {
+	mu       sync.RWMutex
+	prefixes map[string]string // guild id -> prefix
+}
+
+// NewPrefixRegistry returns an empty prefix registry
+func NewPrefixRegistry() *PrefixRegistry {
+	return &PrefixRegistry{
+		prefixes: make(map[string]string),
+	}
+}
+
+// Get returns the prefix for a guild, or the default prefix if the guild hasn't chosen one
+func (registry *PrefixRegistry) Get(guildID string) string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if prefix, ok := registry.prefixes[guildID]; ok {
+		return prefix
+	}
+
+	return DefaultPrefix
+}
+
+// Set stores the prefix for a guild
+func (registry *PrefixRegistry) Set(guildID string, prefix string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.prefixes[guildID] = prefix
+}
+
+// PrefixCommand Changes the prefix the guild's commands are typed with
+func PrefixCommand(ctx *CommandContext, prefix string) {
+	if len(prefix) > MaxPrefixLength {
+		ctx.Reply(fmt.Sprintf("A prefix can be at most %d characters.", MaxPrefixLength))
+		return
+	}
+
+	if err := DBController.SavePrefix(ctx.GuildID, prefix); err != nil {
+		fmt.Println("Error saving prefix:", err)
+		ctx.Reply("Couldn't save the prefix, try again later.")
+		return
+	}
+
+	GuildPrefixes.Set(ctx.GuildID, prefix)
+	ctx.Reply(fmt.Sprintf("Commands are now typed with %s, e.g. \"%s help\".", prefix, prefix))
+}
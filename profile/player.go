@@ -0,0 +1,18 @@
+package profile
+
+// New players start with StartingCredits and the Starter rank
+const (
+	StartingCredits = 1000
+	StarterRankID   = 11
+)
+
+type Player struct {
+	Name    string
+	GuildID string
+	Credits int
+	Wins    int
+	Losses  int
+	Rank    Rank
+
+	//User	discordgo.User
+}
@@ -1,6 +1,7 @@
-package cards
+package profile
 
 import (
+	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"strconv"
@@ -98,28 +99,38 @@ func LoadRankTitles() {
 
 }
 
+// Errors returned when a rank can't be purchased
+var (
+	ErrUnknownRank      = errors.New("there is no rank with that number")
+	ErrRankOwned        = errors.New("you have already purchased this rank")
+	ErrNotEnoughCredits = errors.New("you don't have enough credits to purchase this rank")
+	ErrRankTooHigh      = errors.New("rank not high enough to purchase")
+)
+
 // CanBuyNextRank Check if player rank is high enough to purchase next one (difference of 1 rank up)
-func CanBuyNextRank(session *discordgo.Session, msg *discordgo.MessageCreate, player *Player, rankOption int) bool {
+func CanBuyNextRank(player *Player, rankOption int) error {
+
+	rank, ok := RankMap[rankOption]
+	if !ok {
+		return ErrUnknownRank
+	}
 
 	// If you are a higher rank, cannot purchase a rank below them
-	if player.Rank.RankValue <= RankMap[rankOption].RankValue {
-		session.ChannelMessageSend(msg.ChannelID, "You have already purchased this rank.")
-		return false
+	if player.Rank.RankValue <= rank.RankValue {
+		return ErrRankOwned
 	}
 
 	// Check if player has enough credits to purchase rank
-	if player.Credits < RankMap[rankOption].RankCost {
-		session.ChannelMessageSend(msg.ChannelID, "You don't have enough credits to purchase this rank.")
-		return false
+	if player.Credits < rank.RankCost {
+		return ErrNotEnoughCredits
 	}
 
 	// If player rank is more than 1 rank away, then cannot purchase
-	if player.Rank.RankValue-RankMap[rankOption].RankValue > 1 {
-		session.ChannelMessageSend(msg.ChannelID, "Rank not high enough to purchase.")
-		return false
+	if player.Rank.RankValue-rank.RankValue > 1 {
+		return ErrRankTooHigh
 	}
 
-	return true
+	return nil
 }
 
 // PrintRanksInOrder return ranks in order as a string
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"discordgo-blackjack/handler"
+	"discordgo-blackjack/profile"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ProfileKey identifies a player profile: a user in a guild, or the user's shared profile (GuildID is GlobalWallet)
+type ProfileKey struct {
+	GuildID string
+	UserID  string
+}
+
+// ProfileKeyOf returns the key of the profile the user plays with in the guild
+func ProfileKeyOf(guildID string, userID string) ProfileKey {
+	return ProfileKey{
+		GuildID: SharedWallets.WalletOf(guildID),
+		UserID:  userID,
+	}
+}
+
+// ProfileCache - map of (guild, user) to players that is safe to share between handlers. Profiles are only
+// changed through Change and Commit, which save to the database first (write-through).
+// NOTE: discordgo runs every event handler on its own goroutine, so all access goes through the locks. mu only
+// guards the maps and is never held during a database call. A change is saved holding the lock of each profile
+// it touches instead, so a slow database only holds up the players waiting on it.
+type ProfileCache struct {
+	mu      sync.RWMutex
+	players map[ProfileKey]*profile.Player
+	locks   map[ProfileKey]*sync.Mutex // Held while a change to the profile is decided and saved
+	unsaved []handler.PlayerChange     // Payouts CommitEach couldn't save yet, retried by RetryUnsaved
+}
+
+// NewProfileCache returns an empty profile cache
+func NewProfileCache() *ProfileCache {
+	return &ProfileCache{
+		players: make(map[ProfileKey]*profile.Player),
+		locks:   make(map[ProfileKey]*sync.Mutex),
+	}
+}
+
+// Get returns a copy of the player profile
+func (cache *ProfileCache) Get(key ProfileKey) (profile.Player, bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	player, ok := cache.players[key]
+	if !ok {
+		return profile.Player{}, false
+	}
+
+	return *player, true
+}
+
+// Add stores the player profile unless one is already cached. Returns false if it was already cached.
+func (cache *ProfileCache) Add(key ProfileKey, player *profile.Player) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if _, ok := cache.players[key]; ok {
+		return false
+	}
+
+	cache.players[key] = player
+	return true
+}
+
+// Load caches the profile, loading it from the database or creating a starter profile if the user has none yet.
+// The database is read without holding the lock, so loading a large guild doesn't hold up play everywhere else.
+func (cache *ProfileCache) Load(key ProfileKey, username string) error {
+	if _, ok := cache.Get(key); ok {
+		return nil
+	}
+
+	record, err := Players.Get(key.GuildID, key.UserID)
+	if err == handler.ErrPlayerNotFound {
+		// Create leaves a profile made by another bot instance alone, so read back whichever one was saved
+		err = Players.Create(StarterRecord(key, username))
+		if err == nil {
+			record, err = Players.Get(key.GuildID, key.UserID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Another handler may have cached the profile meanwhile, and may have changed it since, so keep that one
+	cache.Add(key, PlayerFromRecord(record))
+	return nil
+}
+
+// Change decides on a change to a cached profile and saves it. fn gets a copy of the profile and returns the change
+// to make, or an error to make none. Returns handler.ErrPlayerNotFound if the profile isn't cached.
+func (cache *ProfileCache) Change(key ProfileKey, fn func(player profile.Player) (handler.PlayerChange, error)) error {
+	unlock := cache.lockProfiles(key)
+	defer unlock()
+
+	player, ok := cache.Get(key)
+	if !ok {
+		return handler.ErrPlayerNotFound
+	}
+
+	change, err := fn(player)
+	if err != nil {
+		return err
+	}
+	change.GuildID = key.GuildID
+	change.UserID = key.UserID
+
+	return cache.commit([]handler.PlayerChange{change})
+}
+
+// Commit saves the changes in one transaction. Nothing is changed if any of them fails.
+func (cache *ProfileCache) Commit(changes ...handler.PlayerChange) error {
+	unlock := cache.lockProfiles(keysOf(changes)...)
+	defer unlock()
+
+	return cache.commit(changes)
+}
+
+// CommitEach saves credits owed to players, such as the payouts of a round, in one transaction. If that fails
+// each change is saved on its own so one bad profile can't undo everyone else's, and the changes that still fail
+// are kept for RetryUnsaved. Returns the kept changes.
+// NOTE: only for changes that give credits, a change that takes them must not be applied later
+func (cache *ProfileCache) CommitEach(changes ...handler.PlayerChange) []handler.PlayerChange {
+	unlock := cache.lockProfiles(keysOf(changes)...)
+	defer unlock()
+
+	if err := cache.commit(changes); err == nil {
+		return nil
+	}
+
+	var kept []handler.PlayerChange
+	for _, change := range changes {
+		if cache.keepUnsaved(change) != nil {
+			kept = append(kept, change)
+		}
+	}
+
+	return kept
+}
+
+// RetryUnsaved tries to save the changes CommitEach kept again. Returns how many are still unsaved.
+// The first change that still fails ends the pass, so a database that is down is only waited on once.
+func (cache *ProfileCache) RetryUnsaved() int {
+	cache.mu.Lock()
+	unsaved := cache.unsaved
+	cache.unsaved = nil
+	cache.mu.Unlock()
+
+	for index, change := range unsaved {
+		unlock := cache.lockProfiles(ProfileKey{GuildID: change.GuildID, UserID: change.UserID})
+		err := cache.keepUnsaved(change)
+		unlock()
+
+		if err != nil {
+			cache.keep(unsaved[index+1:]...)
+			break
+		}
+	}
+
+	return len(cache.Unsaved())
+}
+
+// Unsaved returns a copy of the changes that are waiting to be saved
+func (cache *ProfileCache) Unsaved() []handler.PlayerChange {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return append([]handler.PlayerChange(nil), cache.unsaved...)
+}
+
+// keepUnsaved saves the change, keeping it to retry later if it fails. Returns the error if it was kept.
+// A change for a profile that doesn't exist is dropped, it can never be saved.
+// The caller holds the lock of the change's profile.
+func (cache *ProfileCache) keepUnsaved(change handler.PlayerChange) error {
+	err := cache.commit([]handler.PlayerChange{change})
+	switch err {
+	case nil:
+		return nil
+	case handler.ErrPlayerNotFound:
+		fmt.Printf("Dropping change for missing profile %s in %s: %+v\n", change.UserID, change.GuildID, change)
+		return nil
+	}
+
+	fmt.Printf("Keeping unsaved change for %s in %s: %+v (%v)\n", change.UserID, change.GuildID, change, err)
+	cache.keep(change)
+	return err
+}
+
+// keep adds changes to the ones waiting for RetryUnsaved
+func (cache *ProfileCache) keep(changes ...handler.PlayerChange) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.unsaved = append(cache.unsaved, changes...)
+}
+
+// commit saves the changes and caches the saved profiles. The caller holds the lock of every profile changed,
+// so no other change to them can be saved in between and the cache always matches the database.
+func (cache *ProfileCache) commit(changes []handler.PlayerChange) error {
+	players, err := Players.ApplyChanges(changes)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for _, player := range players {
+		cache.players[ProfileKey{GuildID: player.GuildID, UserID: player.UserID}] = PlayerFromRecord(player)
+	}
+
+	return nil
+}
+
+// lockProfiles locks each of the profiles and returns the function that unlocks them.
+// The locks are always taken in the same order, so two commits that share profiles can't deadlock.
+func (cache *ProfileCache) lockProfiles(keys ...ProfileKey) func() {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].GuildID != keys[j].GuildID {
+			return keys[i].GuildID < keys[j].GuildID
+		}
+		return keys[i].UserID < keys[j].UserID
+	})
+
+	cache.mu.Lock()
+	var locks []*sync.Mutex
+	for index, key := range keys {
+		if index > 0 && key == keys[index-1] {
+			continue
+		}
+		lock, ok := cache.locks[key]
+		if !ok {
+			lock = &sync.Mutex{}
+			cache.locks[key] = lock
+		}
+		locks = append(locks, lock)
+	}
+	cache.mu.Unlock()
+
+	for _, lock := range locks {
+		lock.Lock()
+	}
+
+	return func() {
+		for _, lock := range locks {
+			lock.Unlock()
+		}
+	}
+}
+
+// keysOf returns the key of the profile each change is made to
+func keysOf(changes []handler.PlayerChange) []ProfileKey {
+	keys := make([]ProfileKey, len(changes))
+	for index, change := range changes {
+		keys[index] = ProfileKey{GuildID: change.GuildID, UserID: change.UserID}
+	}
+
+	return keys
+}
+
+// PlayerFromRecord returns the in-memory profile for a saved player
+func PlayerFromRecord(record handler.PlayerRecord) *profile.Player {
+	return &profile.Player{
+		Name:    record.Username,
+		GuildID: record.GuildID,
+		Credits: record.Credits,
+		Wins:    record.Wins,
+		Losses:  record.Losses,
+		Rank:    profile.RankMap[record.RankID],
+	}
+}
+
+// StarterRecord returns the profile a new player starts with
+func StarterRecord(key ProfileKey, username string) handler.PlayerRecord {
+	return handler.PlayerRecord{
+		UserID:   key.UserID,
+		GuildID:  key.GuildID,
+		Username: username,
+		Credits:  profile.StartingCredits,
+		RankID:   profile.StarterRankID,
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"discordgo-blackjack/handler"
+	"discordgo-blackjack/profile"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryPlayerStore keeps player profiles in memory with the same rules as the database
+type memoryPlayerStore struct {
+	mu      sync.Mutex
+	players map[ProfileKey]handler.PlayerRecord
+	created int  // Profiles created with Create
+	down    bool // ApplyChanges fails like a database that can't be reached
+
+	// Changes to stalledUser wait for stall to be closed, like a database that doesn't answer.
+	// Set before the test starts any goroutine.
+	stalledUser string
+	stalled     chan struct{} // Receives once a change is waiting on stall
+	stall       chan struct{}
+}
+
+// errStoreDown is returned by a memoryPlayerStore that is down
+var errStoreDown = errors.New("store is down")
+
+func newMemoryPlayerStore() *memoryPlayerStore {
+	return &memoryPlayerStore{
+		players: make(map[ProfileKey]handler.PlayerRecord),
+	}
+}
+
+func (store *memoryPlayerStore) Get(guildID string, userID string) (handler.PlayerRecord, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	player, ok := store.players[ProfileKey{GuildID: guildID, UserID: userID}]
+	if !ok {
+		return handler.PlayerRecord{}, handler.ErrPlayerNotFound
+	}
+
+	return player, nil
+}
+
+func (store *memoryPlayerStore) Create(player handler.PlayerRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	key := ProfileKey{GuildID: player.GuildID, UserID: player.UserID}
+	if _, ok := store.players[key]; !ok {
+		store.players[key] = player
+		store.created++
+	}
+
+	return nil
+}
+
+func (store *memoryPlayerStore) Update(player handler.PlayerRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	key := ProfileKey{GuildID: player.GuildID, UserID: player.UserID}
+	if _, ok := store.players[key]; !ok {
+		return handler.ErrPlayerNotFound
+	}
+	store.players[key] = player
+
+	return nil
+}
+
+func (store *memoryPlayerStore) ListByGuild(guildID string) ([]handler.PlayerRecord, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var players []handler.PlayerRecord
+	for key, player := range store.players {
+		if key.GuildID == guildID {
+			players = append(players, player)
+		}
+	}
+
+	return players, nil
+}
+
+func (store *memoryPlayerStore) AdjustCredits(guildID string, userID string, amount int) (int, error) {
+	players, err := store.ApplyChanges([]handler.PlayerChange{{GuildID: guildID, UserID: userID, Credits: amount}})
+	if err != nil {
+		return 0, err
+	}
+
+	return players[0].Credits, nil
+}
+
+func (store *memoryPlayerStore) ApplyChanges(changes []handler.PlayerChange) ([]handler.PlayerRecord, error) {
+	for _, change := range changes {
+		if store.stall != nil && change.UserID == store.stalledUser {
+			store.stalled <- struct{}{}
+			<-store.stall
+			break
+		}
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.down {
+		return nil, errStoreDown
+	}
+
+	// Work on copies so a failed change leaves every profile as it was
+	updated := make(map[ProfileKey]handler.PlayerRecord)
+	players := make([]handler.PlayerRecord, len(changes))
+	for index, change := range changes {
+		key := ProfileKey{GuildID: change.GuildID, UserID: change.UserID}
+		player, ok := updated[key]
+		if !ok {
+			player, ok = store.players[key]
+		}
+		if !ok {
+			return nil, handler.ErrPlayerNotFound
+		}
+		if player.Credits+change.Credits < 0 {
+			return nil, handler.ErrInsufficientCredits
+		}
+
+		player.Credits += change.Credits
+		player.Wins += change.Wins
+		player.Losses += change.Losses
+		if change.RankID > 0 {
+			player.RankID = change.RankID
+		}
+		updated[key] = player
+		players[index] = player
+	}
+
+	for key, player := range updated {
+		store.players[key] = player
+	}
+
+	return players, nil
+}
+
+// useMemoryStore points Players and UserProfiles at an empty in-memory store for the test
+func useMemoryStore(t *testing.T) *memoryPlayerStore {
+	store := newMemoryPlayerStore()
+
+	oldPlayers, oldProfiles := Players, UserProfiles
+	Players, UserProfiles = store, NewProfileCache()
+	t.Cleanup(func() {
+		Players, UserProfiles = oldPlayers, oldProfiles
+	})
+
+	return store
+}
+
+func TestConcurrentChangesNeverOverdraw(t *testing.T) {
+	store := useMemoryStore(t)
+	key := ProfileKey{GuildID: "guild", UserID: "player"}
+	if err := UserProfiles.Load(key, "player"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Twice as many bets as the starting credits can cover, all at once
+	const bet = 10
+	attempts := 2 * profile.StartingCredits / bet
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	placed := 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := UserProfiles.Change(key, func(player profile.Player) (handler.PlayerChange, error) {
+				if player.Credits < bet {
+					return handler.PlayerChange{}, handler.ErrInsufficientCredits
+				}
+				return handler.PlayerChange{Credits: -bet}, nil
+			})
+			if err == nil {
+				mu.Lock()
+				placed++
+				mu.Unlock()
+			}
+
+			// Readers run alongside the writers
+			UserProfiles.Get(key)
+		}()
+	}
+	wg.Wait()
+
+	if placed != profile.StartingCredits/bet {
+		t.Fatalf("%d bets were placed, want %d", placed, profile.StartingCredits/bet)
+	}
+
+	cached, _ := UserProfiles.Get(key)
+	saved, _ := store.Get(key.GuildID, key.UserID)
+	if cached.Credits != 0 || saved.Credits != 0 {
+		t.Fatalf("credits left: %d cached, %d saved, want 0", cached.Credits, saved.Credits)
+	}
+}
+
+func TestConcurrentLoadsCreateOneProfile(t *testing.T) {
+	store := useMemoryStore(t)
+	key := ProfileKey{GuildID: "guild", UserID: "newcomer"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := UserProfiles.Load(key, "newcomer"); err != nil {
+				t.Errorf("Load: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if store.created != 1 {
+		t.Fatalf("%d profiles were created, want 1", store.created)
+	}
+	if player, ok := UserProfiles.Get(key); !ok || player.Credits != profile.StartingCredits {
+		t.Fatalf("cached profile = %+v, %v", player, ok)
+	}
+}
+
+func TestCommitEachKeepsFailedPayouts(t *testing.T) {
+	store := useMemoryStore(t)
+	paid := ProfileKey{GuildID: "guild", UserID: "paid"}
+	if err := UserProfiles.Load(paid, "paid"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// The missing profile fails the whole transaction, but mustn't keep the other seat from being paid
+	unsaved := UserProfiles.CommitEach(
+		handler.PlayerChange{GuildID: paid.GuildID, UserID: paid.UserID, Credits: 20, Wins: 1},
+		handler.PlayerChange{GuildID: "guild", UserID: "missing", Credits: 20, Wins: 1},
+	)
+	if len(unsaved) != 0 {
+		t.Fatalf("kept %+v, a missing profile can never be saved", unsaved)
+	}
+
+	saved, _ := store.Get(paid.GuildID, paid.UserID)
+	if saved.Credits != profile.StartingCredits+20 || saved.Wins != 1 {
+		t.Fatalf("paid seat saved as %+v", saved)
+	}
+	if left := UserProfiles.RetryUnsaved(); left != 0 {
+		t.Fatalf("%d changes left to retry, want 0", left)
+	}
+}
+
+func TestRetryUnsavedPaysOnceTheStoreIsBack(t *testing.T) {
+	store := useMemoryStore(t)
+	key := ProfileKey{GuildID: "guild", UserID: "player"}
+	if err := UserProfiles.Load(key, "player"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	store.mu.Lock()
+	store.down = true
+	store.mu.Unlock()
+
+	payout := handler.PlayerChange{GuildID: key.GuildID, UserID: key.UserID, Credits: 20, Wins: 1}
+	if unsaved := UserProfiles.CommitEach(payout); len(unsaved) != 1 {
+		t.Fatalf("kept %d changes while the store is down, want 1", len(unsaved))
+	}
+	if left := UserProfiles.RetryUnsaved(); left != 1 {
+		t.Fatalf("%d changes left to retry while the store is down, want 1", left)
+	}
+
+	store.mu.Lock()
+	store.down = false
+	store.mu.Unlock()
+
+	if left := UserProfiles.RetryUnsaved(); left != 0 {
+		t.Fatalf("%d changes left to retry, want 0", left)
+	}
+	if left := UserProfiles.RetryUnsaved(); left != 0 {
+		t.Fatalf("%d changes left after a second retry, want 0", left)
+	}
+
+	// Paid exactly once, however many times it was retried
+	saved, _ := store.Get(key.GuildID, key.UserID)
+	cached, _ := UserProfiles.Get(key)
+	if saved.Credits != profile.StartingCredits+20 || cached.Credits != saved.Credits || saved.Wins != 1 {
+		t.Fatalf("profile saved as %+v and cached with %d credits", saved, cached.Credits)
+	}
+}
+
+func TestStalledSaveOnlyHoldsUpItsProfile(t *testing.T) {
+	store := useMemoryStore(t)
+	stalled := ProfileKey{GuildID: "guild", UserID: "stalled"}
+	other := ProfileKey{GuildID: "guild", UserID: "other"}
+	for _, key := range []ProfileKey{stalled, other} {
+		if err := UserProfiles.Load(key, key.UserID); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+	}
+
+	store.stalledUser = stalled.UserID
+	store.stalled = make(chan struct{})
+	store.stall = make(chan struct{})
+
+	saved := make(chan error)
+	go func() {
+		saved <- UserProfiles.Commit(handler.PlayerChange{GuildID: stalled.GuildID, UserID: stalled.UserID, Credits: 10})
+	}()
+	<-store.stalled
+
+	// Other players keep betting and every profile can still be read while the save waits on the database
+	done := make(chan error)
+	go func() {
+		UserProfiles.Get(stalled)
+		done <- UserProfiles.Change(other, func(player profile.Player) (handler.PlayerChange, error) {
+			return handler.PlayerChange{Credits: -10}, nil
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Change: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		close(store.stall)
+		t.Fatal("a change to another profile waited on the stalled save")
+	}
+
+	close(store.stall)
+	if err := <-saved; err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if player, _ := UserProfiles.Get(stalled); player.Credits != profile.StartingCredits+10 {
+		t.Fatalf("stalled profile has %d credits cached, want %d", player.Credits, profile.StartingCredits+10)
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Permission is who is allowed to run a command
+type Permission int
+
+const (
+	Everyone  Permission = iota
+	AdminOnly            // Server admins (Administrator or Manage Server)
+)
+
+// ArgType is the kind of value a command argument takes
+type ArgType int
+
+const (
+	WordArg   ArgType = iota // A single word
+	AmountArg                // A whole number above zero
+	TextArg                  // The rest of the message, only allowed as the last argument
+)
+
+// Arg describes one argument of a command
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	Choices  []string // Words a WordArg accepts, any word if empty
+}
+
+// Command is a command players can type after the guild's prefix
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string // Shown in help
+	Args        []Arg
+	Permission  Permission
+	Run         func(ctx *CommandContext, args Args)
+}
+
+// Usage returns how the command is typed, e.g. "!game table <open|join|deal|leave|close> [amount]"
+func (command *Command) Usage(prefix string) string {
+	usage := []string{prefix, command.Name}
+	for _, arg := range command.Args {
+		name := arg.Name
+		if len(arg.Choices) > 0 {
+			name = strings.Join(arg.Choices, "|")
+		}
+
+		if arg.Optional {
+			usage = append(usage, "["+name+"]")
+		} else {
+			usage = append(usage, "<"+name+">")
+		}
+	}
+
+	return strings.Join(usage, " ")
+}
+
+// ErrTooManyArgs is returned when a command is given more arguments than it takes
+var ErrTooManyArgs = errors.New("too many arguments")
+
+// ParseArgs checks the words typed after the command name against its arguments
+func (command *Command) ParseArgs(words []string) (Args, error) {
+	args := make(Args)
+
+	for index, arg := range command.Args {
+		if index >= len(words) {
+			if arg.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("missing %s", arg.Name)
+		}
+
+		word := words[index]
+		switch arg.Type {
+		case TextArg:
+			args[arg.Name] = strings.Join(words[index:], " ")
+			return args, nil
+
+		case AmountArg:
+			if amount, err := strconv.Atoi(word); err != nil || amount <= 0 {
+				return nil, fmt.Errorf("%s must be a whole number above 0", arg.Name)
+			}
+
+		case WordArg:
+			if len(arg.Choices) > 0 && !containsWord(arg.Choices, word) {
+				return nil, fmt.Errorf("%s must be one of %s", arg.Name, strings.Join(arg.Choices, ", "))
+			}
+		}
+
+		args[arg.Name] = word
+	}
+
+	if len(words) > len(command.Args) {
+		return nil, ErrTooManyArgs
+	}
+
+	return args, nil
+}
+
+// containsWord returns true if the word is one of the choices
+func containsWord(choices []string, word string) bool {
+	for _, choice := range choices {
+		if choice == word {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Args holds the arguments a command was run with, by name. Values were checked against the command's Args.
+type Args map[string]string
+
+// Has returns true if the argument was given
+func (args Args) Has(name string) bool {
+	_, ok := args[name]
+	return ok
+}
+
+// String returns the argument, or "" if it wasn't given
+func (args Args) String(name string) string {
+	return args[name]
+}
+
+// Amount returns the AmountArg, or the fallback if it wasn't given
+func (args Args) Amount(name string, fallback int) int {
+	amount, err := strconv.Atoi(args[name])
+	if err != nil {
+		return fallback
+	}
+
+	return amount
+}
+
+// CommandRegistry holds every command by name and alias
+// NOTE: commands are only registered at startup, so the registry is safe to read from any goroutine afterwards
+type CommandRegistry struct {
+	commands []*Command          // In the order they are shown in help
+	names    map[string]*Command // Name or alias -> command
+}
+
+// NewCommandRegistry returns an empty command registry
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		names: make(map[string]*Command),
+	}
+}
+
+// Register adds a command. Panics if its name or an alias is already taken.
+func (registry *CommandRegistry) Register(command *Command) {
+	for _, name := range append([]string{command.Name}, command.Aliases...) {
+		if _, taken := registry.names[name]; taken {
+			panic(fmt.Sprintf("command name %q registered twice", name))
+		}
+		registry.names[name] = command
+	}
+
+	registry.commands = append(registry.commands, command)
+}
+
+// Find returns the command with the given name or alias, ignoring case
+func (registry *CommandRegistry) Find(name string) (*Command, bool) {
+	command, ok := registry.names[strings.ToLower(name)]
+	return command, ok
+}
+
+// All returns every command in the order they were registered
+func (registry *CommandRegistry) All() []*Command {
+	return registry.commands
+}
+
+// SplitCommand Returns the words typed after the prefix. Returns false if the message isn't a command.
+func SplitCommand(content string, prefix string) ([]string, bool) {
+	if !strings.HasPrefix(content, prefix) {
+		return nil, false
+	}
+
+	// A prefix ending in a letter needs a space after it, so "!gamer" isn't read as "!game r"
+	rest := content[len(prefix):]
+	last, _ := utf8.DecodeLastRuneInString(prefix)
+	first, _ := utf8.DecodeRuneInString(rest)
+	if rest != "" && (unicode.IsLetter(last) || unicode.IsDigit(last)) && !unicode.IsSpace(first) {
+		return nil, false
+	}
+
+	return strings.Fields(rest), true
+}
+
+// CommandHandler Handles commands when user types in a message
+func CommandHandler(session *discordgo.Session, msg *discordgo.MessageCreate) {
+	// Ignore self-messages from bot
+	if msg.Author.ID == session.State.User.ID {
+		return
+	}
+
+	prefix := GuildPrefixes.Get(msg.GuildID)
+	words, ok := SplitCommand(msg.Content, prefix)
+	if !ok {
+		return
+	}
+
+	ctx := NewMessageContext(session, msg)
+
+	// First word should be the command name
+	if len(words) == 0 {
+		ctx.Reply(fmt.Sprintf("Type \"%s help\" for a list of game commands", prefix))
+		return
+	}
+
+	command, ok := Commands.Find(words[0])
+	if !ok {
+		ctx.Reply(fmt.Sprintf("Type \"%s help\" for a list of game commands", prefix))
+		return
+	}
+
+	EnsurePlayerProfile(ctx)
+	RunCommand(ctx, command, words[1:])
+}
+
+// RunCommand Checks the user may run the command and that its arguments are valid, then runs it
+func RunCommand(ctx *CommandContext, command *Command, words []string) {
+	if command.Permission == AdminOnly && !IsGuildAdmin(ctx.Session, ctx.UserID, ctx.ChannelID) {
+		ctx.ReplyPrivate(fmt.Sprintf("Only server admins can use %s.", command.Name))
+		return
+	}
+
+	args, err := command.ParseArgs(words)
+	if err != nil {
+		ctx.Reply(fmt.Sprintf("Can't run %s: %v. Usage: %s", command.Name, err, command.Usage(ctx.Prefix())))
+		return
+	}
+
+	command.Run(ctx, args)
+}
+
+// HelpCommand Lists every command, or shows how to use one of them
+func HelpCommand(ctx *CommandContext, args Args) {
+	prefix := ctx.Prefix()
+
+	if args.Has("command") {
+		command, ok := Commands.Find(args.String("command"))
+		if !ok {
+			ctx.Reply(fmt.Sprintf("There is no %s command. Type \"%s help\" for a list of game commands", args.String("command"), prefix))
+			return
+		}
+
+		ctx.ReplyEmbed(&discordgo.MessageEmbed{
+			Title:       command.Usage(prefix),
+			Description: CommandDescription(command),
+			Color:       0,
+		})
+		return
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	for _, command := range Commands.All() {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  command.Usage(prefix),
+			Value: CommandDescription(command),
+		})
+	}
+
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:  "Slash commands",
+		Value: "/blackjack, /wallet, /stats, /shop and /buy work too. Your wallet, stats and the shop are only shown to you.",
+	})
+
+	ctx.ReplyEmbed(&discordgo.MessageEmbed{
+		Title:       "List of Commands",
+		Description: fmt.Sprintf("List of Commands available. Type \"%s help <command>\" to see one of them.", prefix),
+		Color:       0,
+		Fields:      fields,
+	})
+}
+
+// CommandDescription Returns the help text of a command with its aliases and who can use it
+func CommandDescription(command *Command) string {
+	description := command.Description
+	if len(command.Aliases) > 0 {
+		description += fmt.Sprintf(" (also: %s)", strings.Join(command.Aliases, ", "))
+	}
+	if command.Permission == AdminOnly {
+		description += " - admins only"
+	}
+
+	return description
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"discordgo-blackjack/blackjack"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// GuildRules - Holds the table rules each guild plays with
+var GuildRules = NewRulesRegistry()
+
+// RulesRegistry keeps the table rules for each guild
+type RulesRegistry struct {
+	mu    sync.RWMutex
+	rules map[string]blackjack.TableRules // guild id -> rules
+}
+
+// NewRulesRegistry returns an empty rules registry
+func NewRulesRegistry() *RulesRegistry {
+	return &RulesRegistry{
+		rules: make(map[string]blackjack.TableRules),
+	}
+}
+
+// Get returns the rules for a guild, or the default rules if the guild hasn't set any
+func (registry *RulesRegistry) Get(guildID string) blackjack.TableRules {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if rules, ok := registry.rules[guildID]; ok {
+		return rules
+	}
+
+	return blackjack.DefaultTableRules()
+}
+
+// Set stores the rules for a guild
+func (registry *RulesRegistry) Set(guildID string, rules blackjack.TableRules) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.rules[guildID] = rules
+}
+
+// IsGuildAdmin returns true if the user can manage the server the channel belongs to
+func IsGuildAdmin(session *discordgo.Session, userID string, channelID string) bool {
+	permissions, err := session.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		fmt.Println("Error checking user permissions")
+		return false
+	}
+
+	return permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0
+}
+
+// RuleSettings Names of the house rules that can be changed with the rules command, in the order they are shown
+var RuleSettings = []string{"minbet", "maxbet", "decks", "penetration", "h17", "payout", "splits", "double", "das", "surrender"}
+
+// RulesCommand Shows the guild's house rules, admins can change one by giving the setting and its value
+func RulesCommand(ctx *CommandContext, setting string, value string) {
+
+	rules := GuildRules.Get(ctx.GuildID)
+
+	// Without arguments just show the current rules
+	if setting == "" {
+		DisplayRules(ctx, rules)
+		return
+	}
+
+	if !IsGuildAdmin(ctx.Session, ctx.UserID, ctx.ChannelID) {
+		ctx.Reply("Only server admins can change the house rules.")
+		return
+	}
+
+	if value == "" {
+		ctx.Reply(fmt.Sprintf("Usage: %s rules <setting> <value>", ctx.Prefix()))
+		return
+	}
+
+	if err := ChangeRule(&rules, setting, value); err != nil {
+		ctx.Reply(fmt.Sprintf("Can't change rules: %v.", err))
+		return
+	}
+
+	if err := rules.Validate(); err != nil {
+		ctx.Reply(fmt.Sprintf("Can't change rules: %v.", err))
+		return
+	}
+
+	if err := DBController.SaveTableRules(ctx.GuildID, rules); err != nil {
+		fmt.Println("Error saving table rules:", err)
+		ctx.Reply("Couldn't save the house rules, try again later.")
+		return
+	}
+
+	GuildRules.Set(ctx.GuildID, rules)
+	ctx.Reply(fmt.Sprintf("House rule %s is now %s. The next round at every table uses it.", setting, RuleValue(rules, setting)))
+}
+
+// ChangeRule Sets one of the house rules from its text value
+func ChangeRule(rules *blackjack.TableRules, setting string, value string) error {
+	switch setting {
+	case "minbet", "maxbet", "decks", "penetration", "splits":
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", setting)
+		}
+
+		switch setting {
+		case "minbet":
+			rules.MinBet = number
+		case "maxbet":
+			rules.MaxBet = number
+		case "decks":
+			rules.Decks = number
+		case "penetration":
+			rules.Penetration = number
+		case "splits":
+			rules.MaxSplitHands = number
+		}
+
+	case "h17", "das":
+		var enabled bool
+		switch value {
+		case "on":
+			enabled = true
+		case "off":
+			enabled = false
+		default:
+			return fmt.Errorf("%s must be on or off", setting)
+		}
+
+		if setting == "h17" {
+			rules.DealerHitsSoft17 = enabled
+		} else {
+			rules.DoubleAfterSplit = enabled
+		}
+
+	case "payout":
+		switch value {
+		case "3:2":
+			rules.BlackjackPays = blackjack.ThreeToTwo
+		case "6:5":
+			rules.BlackjackPays = blackjack.SixToFive
+		default:
+			return fmt.Errorf("payout must be 3:2 or 6:5")
+		}
+
+	case "double":
+		switch value {
+		case "any":
+			rules.DoubleOn = blackjack.DoubleAnyTwo
+		case "9-11":
+			rules.DoubleOn = blackjack.DoubleNineToEleven
+		default:
+			return fmt.Errorf("double must be any or 9-11")
+		}
+
+	case "surrender":
+		switch value {
+		case "none":
+			rules.Surrender = blackjack.NoSurrender
+		case "late":
+			rules.Surrender = blackjack.LateSurrender
+		case "early":
+			rules.Surrender = blackjack.EarlySurrender
+		default:
+			return fmt.Errorf("surrender must be none, late or early")
+		}
+
+	default:
+		return fmt.Errorf("unknown setting %q", setting)
+	}
+
+	return nil
+}
+
+// RuleValue Returns the text value of one of the house rules
+func RuleValue(rules blackjack.TableRules, setting string) string {
+	onOff := map[bool]string{true: "on", false: "off"}
+
+	switch setting {
+	case "minbet":
+		return strconv.Itoa(rules.MinBet)
+	case "maxbet":
+		return strconv.Itoa(rules.MaxBet)
+	case "decks":
+		return strconv.Itoa(rules.Decks)
+	case "penetration":
+		return fmt.Sprintf("%d%%", rules.Penetration)
+	case "splits":
+		return strconv.Itoa(rules.MaxSplitHands)
+	case "h17":
+		return onOff[rules.DealerHitsSoft17]
+	case "das":
+		return onOff[rules.DoubleAfterSplit]
+	case "payout":
+		if rules.BlackjackPays == blackjack.SixToFive {
+			return "6:5"
+		}
+		return "3:2"
+	case "double":
+		if rules.DoubleOn == blackjack.DoubleNineToEleven {
+			return "9-11"
+		}
+		return "any"
+	case "surrender":
+		switch rules.Surrender {
+		case blackjack.LateSurrender:
+			return "late"
+		case blackjack.EarlySurrender:
+			return "early"
+		}
+		return "none"
+	}
+
+	return ""
+}
+
+// DisplayRules Shows the house rules of the guild
+func DisplayRules(ctx *CommandContext, rules blackjack.TableRules) {
+	descriptions := map[string]string{
+		"minbet":      "Minimum bet",
+		"maxbet":      "Maximum bet",
+		"decks":       "Decks in the shoe",
+		"penetration": "Shoe dealt before reshuffling",
+		"h17":         "Dealer hits soft 17",
+		"payout":      "Blackjack pays",
+		"splits":      "Most hands after splitting",
+		"double":      "Double down on",
+		"das":         "Double after split",
+		"surrender":   "Surrender",
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	for _, setting := range RuleSettings {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (%s)", descriptions[setting], setting),
+			Value:  RuleValue(rules, setting),
+			Inline: true,
+		})
+	}
+
+	rulesEmbed := &discordgo.MessageEmbed{
+		Title:       "House Rules",
+		Description: fmt.Sprintf("Admins can change a rule with \"%s rules <setting> <value>\"", ctx.Prefix()),
+		Color:       0,
+		Fields:      fields,
+	}
+
+	ctx.ReplyEmbed(rulesEmbed)
+}
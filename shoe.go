@@ -0,0 +1,223 @@
+package main
+
+import (
+	"database/sql"
+	"discordgo-blackjack/blackjack"
+	"discordgo-blackjack/cards"
+	"discordgo-blackjack/handler"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MaxClientSeedLength is the longest client seed players can set
+const MaxClientSeedLength = 100
+
+// PrepareShoe Makes sure the table's shoe is ready for the round about to be dealt. The shoe is only shuffled
+// between rounds: once the cut card has come out, when the house rules for the shoe changed, or when the cards
+// left might not cover the round for everyone seated.
+func PrepareShoe(session *discordgo.Session, table *Table, rules blackjack.TableRules) {
+	switch {
+	case table.Shoe == nil:
+	case table.Shoe.Decks != rules.Decks || table.Shoe.Penetration != rules.Penetration:
+		session.ChannelMessageSend(table.ChannelID,
+			fmt.Sprintf("The house rules changed, a fresh %d deck shoe is brought out.", rules.Decks))
+	case table.Shoe.NeedsShuffle():
+		session.ChannelMessageSend(table.ChannelID, "The cut card came out last round. Reshuffling the shoe...")
+	case !rules.ShoeCovers(table.Shoe, len(table.Round.Seats)):
+		session.ChannelMessageSend(table.ChannelID,
+			"Not enough cards are left in the shoe for everyone seated. Reshuffling the shoe...")
+	default:
+		return
+	}
+
+	// The old shoe is finished, so its server seed can be revealed before the next one is shuffled
+	EndShoe(session, table)
+
+	table.Shoe = &cards.Shoe{
+		Decks:       rules.Decks,
+		Penetration: rules.Penetration,
+	}
+	ShuffleShoe(session, table)
+}
+
+// ShuffleShoe Shuffles the table's shoe from the server seed committed to for it, then commits to the server
+// seed of the shoe after it. Client seeds set from now on are only used with that seed, whose hash is public.
+func ShuffleShoe(session *discordgo.Session, table *Table) {
+	if table.ClientSeed == "" {
+		table.ClientSeed = cards.NewServerSeed()[:16]
+	}
+
+	table.Shoe.ShuffleFair(table.NextServerSeed, table.ClientSeed)
+	table.NextServerSeed = cards.NewServerSeed()
+
+	shoeID, err := DBController.CreateShoe(handler.ShoeRecord{
+		GuildID:        table.GuildID,
+		ChannelID:      table.ChannelID,
+		Decks:          table.Shoe.Decks,
+		ServerSeedHash: table.Shoe.ServerSeedHash(),
+		ServerSeed:     table.Shoe.ServerSeed,
+		ClientSeed:     table.Shoe.ClientSeed,
+	})
+	if err != nil {
+		fmt.Println("Error saving shoe seeds:", err)
+	}
+	table.ShoeID = shoeID
+
+	shuffled := fmt.Sprintf("New shoe #%d shuffled", table.ShoeID)
+	if table.ShoeID == 0 {
+		shuffled = "New shoe shuffled, but it couldn't be recorded so it can't be verified with the bot"
+	}
+	session.ChannelMessageSend(table.ChannelID,
+		fmt.Sprintf("%s. Server seed hash: `%s`, client seed: `%s`. "+
+			"The server seed is revealed once the shoe is finished.\n%s",
+			shuffled, table.Shoe.ServerSeedHash(), table.Shoe.ClientSeed, NextShoeCommitment(table)))
+}
+
+// NextShoeCommitment Publishes the hash of the server seed the table's next shoe is shuffled with
+func NextShoeCommitment(table *Table) string {
+	return fmt.Sprintf("The next shoe's server seed hash is `%s`. Set its client seed with \"%s seed <text>\".",
+		cards.HashSeed(table.NextServerSeed), GuildPrefixes.Get(table.GuildID))
+}
+
+// EndShoe Reveals the server seed of the table's shoe once no more cards will be dealt from it
+func EndShoe(session *discordgo.Session, table *Table) {
+	if table.Shoe == nil || table.Shoe.ServerSeed == "" {
+		return
+	}
+
+	if table.ShoeID == 0 {
+		session.ChannelMessageSend(table.ChannelID,
+			fmt.Sprintf("The unrecorded shoe is finished. Server seed: `%s`, client seed: `%s`.",
+				table.Shoe.ServerSeed, table.Shoe.ClientSeed))
+		return
+	}
+
+	if err := DBController.RevealShoe(table.ShoeID); err != nil {
+		fmt.Println("Error revealing shoe:", err)
+	}
+
+	session.ChannelMessageSend(table.ChannelID,
+		fmt.Sprintf("Shoe #%d is finished. Server seed: `%s`. Type \"%s verify %d\" to check the shuffle.",
+			table.ShoeID, table.Shoe.ServerSeed, GuildPrefixes.Get(table.GuildID), table.ShoeID))
+}
+
+// ShoeCommand Shows how much of the table's shoe is left before the cut card
+func ShoeCommand(ctx *CommandContext) {
+
+	table, ok := Tables.ByChannel(ctx.ChannelID)
+	if !ok {
+		ctx.Reply("There is no table open in this channel.")
+		return
+	}
+
+	table.Lock()
+	defer table.Unlock()
+
+	if table.Shoe == nil {
+		ctx.Reply("No cards have been dealt at this table yet.")
+		return
+	}
+
+	ctx.Reply(ShoeStatus(table))
+}
+
+// ShoeStatus Describes the cards left in the table's shoe and the hash it was shuffled with
+func ShoeStatus(table *Table) string {
+	shoe := table.Shoe
+
+	name := fmt.Sprintf("Shoe #%d", table.ShoeID)
+	if table.ShoeID == 0 {
+		name = "Unrecorded shoe"
+	}
+
+	status := fmt.Sprintf("%s: %d cards (%.1f decks) left of %d decks, cut card at %d%%",
+		name, shoe.CardsRemaining(), shoe.DecksRemaining(), shoe.Decks, shoe.Penetration)
+	if shoe.NeedsShuffle() {
+		status += " - reshuffling before the next round"
+	}
+
+	return status + fmt.Sprintf("\nServer seed hash: %s", shoe.ServerSeedHash())
+}
+
+// SeedCommand Shows the table's client seed, or sets the one the next shoe is shuffled with. The server seed of
+// that shoe is committed to before any client seed can be set, see ShuffleShoe.
+func SeedCommand(ctx *CommandContext, seed string) {
+
+	table, ok := Tables.ByChannel(ctx.ChannelID)
+	if !ok {
+		ctx.Reply("There is no table open in this channel.")
+		return
+	}
+
+	table.Lock()
+	defer table.Unlock()
+
+	if seed == "" {
+		ctx.Reply(
+			fmt.Sprintf("The next shoe is shuffled with client seed `%s` and the server seed with hash `%s`. "+
+				"Change the client seed with \"%s seed <text>\".",
+				table.ClientSeed, cards.HashSeed(table.NextServerSeed), ctx.Prefix()))
+		return
+	}
+
+	if len(seed) > MaxClientSeedLength {
+		ctx.Reply(
+			fmt.Sprintf("A client seed can be at most %d characters.", MaxClientSeedLength))
+		return
+	}
+
+	table.ClientSeed = seed
+	ctx.Reply(
+		fmt.Sprintf("%s set the client seed to `%s`. It is used with the next shoe, server seed hash `%s`.",
+			ctx.Username, seed, cards.HashSeed(table.NextServerSeed)))
+}
+
+// VerifyCommand Recomputes the card order of a finished shoe from its revealed seeds
+func VerifyCommand(ctx *CommandContext, shoeID int64) {
+
+	shoe, err := DBController.LoadShoe(shoeID)
+	if err == sql.ErrNoRows || (err == nil && shoe.GuildID != ctx.GuildID) {
+		ctx.Reply(fmt.Sprintf("There is no shoe #%d in this server.", shoeID))
+		return
+	}
+	if err != nil {
+		fmt.Println("Error loading shoe:", err)
+		ctx.Reply("Couldn't load that shoe, try again later.")
+		return
+	}
+
+	if !shoe.Revealed {
+		ctx.Reply(
+			fmt.Sprintf("Shoe #%d is still being dealt. Its server seed is revealed once the shoe is finished.", shoeID))
+		return
+	}
+
+	hashMatches := "matches"
+	if cards.HashSeed(shoe.ServerSeed) != shoe.ServerSeedHash {
+		hashMatches = "DOES NOT match"
+	}
+
+	// Attach the whole order so players can compare it with the cards that were dealt (13 cards a line, e.g. "AS TD 9H")
+	var order strings.Builder
+	shuffled := cards.ShuffledCards(shoe.Decks, shoe.ServerSeed, shoe.ClientSeed)
+	for start := 0; start < len(shuffled); start += 13 {
+		end := start + 13
+		if end > len(shuffled) {
+			end = len(shuffled)
+		}
+		fmt.Fprintf(&order, "%3d: %s\n", start+1, cards.EncodeCards(shuffled[start:end]))
+	}
+
+	content := fmt.Sprintf("Shoe #%d (%d decks)\nServer seed: `%s`\nClient seed: `%s`\n"+
+		"SHA-256 of the server seed %s the published hash `%s`. The card order is attached.",
+		shoe.ID, shoe.Decks, shoe.ServerSeed, shoe.ClientSeed, hashMatches, shoe.ServerSeedHash)
+
+	_, err = ctx.Session.ChannelFileSendWithMessage(ctx.ChannelID, content,
+		fmt.Sprintf("shoe-%d.txt", shoe.ID), strings.NewReader(order.String()))
+	if err != nil {
+		fmt.Println("Error sending shoe order")
+		return
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// minBetValue is the smallest bet the /blackjack option accepts, the table rules check the rest
+var minBetValue float64 = 1
+
+// SlashCommands are the application commands registered in every guild, each runs the same code as its !game command
+var SlashCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "blackjack",
+		Description: "Play a round of blackjack at the channel's table",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "bet",
+				Description: "Credits to bet, the table minimum if left out",
+				MinValue:    &minBetValue,
+			},
+		},
+	},
+	{
+		Name:        "wallet",
+		Description: "Show how many credits you have",
+	},
+	{
+		Name:        "stats",
+		Description: "Show your win-loss record and rank",
+	},
+	{
+		Name:        "shop",
+		Description: "Show the titles you can purchase",
+	},
+	{
+		Name:        "buy",
+		Description: "Purchase the next rank title",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "rank",
+				Description: "Number of the rank in the shop",
+				Required:    true,
+			},
+		},
+	},
+}
+
+// RegisterSlashCommands Registers the slash commands in the guild, replacing the ones registered before
+func RegisterSlashCommands(session *discordgo.Session, guildID string) {
+	_, err := session.ApplicationCommandBulkOverwrite(session.State.User.ID, guildID, SlashCommands)
+	if err != nil {
+		fmt.Println("Error registering slash commands for guild", guildID, err)
+	}
+}
+
+// InteractionHandler Handles slash commands and button presses
+func InteractionHandler(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	// Slash commands and tables only exist in guilds
+	if interaction.Member == nil {
+		return
+	}
+
+	switch interaction.Type {
+	case discordgo.InteractionApplicationCommand:
+		SlashCommandHandler(session, interaction)
+	case discordgo.InteractionMessageComponent:
+		ButtonHandler(session, interaction)
+	}
+}
+
+// SlashCommandHandler Runs the slash command with the same code as its !game command
+func SlashCommandHandler(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	ctx := NewInteractionContext(session, interaction)
+	command := interaction.ApplicationCommandData()
+
+	// Acknowledge the command before touching the database, Discord only waits 3 seconds and a first-time player's
+	// profile alone takes up to three round trips. Commands that only answer the player load privately.
+	switch command.Name {
+	case "wallet", "stats", "shop":
+		ctx.DeferPrivate()
+	default:
+		ctx.Defer()
+	}
+	EnsurePlayerProfile(ctx)
+
+	// Options are keyed by name, Discord already checked their types
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, option := range command.Options {
+		options[option.Name] = option
+	}
+
+	switch command.Name {
+	case "blackjack":
+		bet := NoBetGiven
+		if option, ok := options["bet"]; ok {
+			bet = int(option.IntValue())
+		}
+		PlayBlackjack(ctx, bet)
+	case "wallet":
+		DisplayPlayerCredits(ctx)
+	case "stats":
+		DisplayPlayerStats(ctx)
+	case "shop":
+		DisplayGameShop(ctx)
+	case "buy":
+		PurchaseRankTitle(ctx, int(options["rank"].IntValue()))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// runSlashCommand runs a slash command as the user
+func runSlashCommand(session *discordgo.Session, userID string, name string) {
+	InteractionHandler(session, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "command",
+		Token:     "token",
+		AppID:     "app",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "slash",
+		GuildID:   "guild",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: userID, Username: userID}},
+		Data:      discordgo.ApplicationCommandInteractionData{Name: name},
+	}})
+}
+
+func TestPrivateCommandIsAcknowledgedFirst(t *testing.T) {
+	useMemoryStore(t)
+	session, stub := newTestSession(t)
+
+	// A first-time player, so their profile is created before the wallet can be shown
+	runSlashCommand(session, "newcomer", "wallet")
+
+	stub.mu.Lock()
+	requests := append([]string(nil), stub.requests...)
+	stub.mu.Unlock()
+
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want the loading message and the answer: %q", len(requests), requests)
+	}
+	if first := requests[0]; !strings.HasPrefix(first, "POST /api/v9/interactions/command/token/callback ") ||
+		!strings.Contains(first, `"type":5`) || !strings.Contains(first, `"flags":64`) {
+		t.Fatalf("first request is %q, want a private loading message", first)
+	}
+	if answer := requests[1]; !strings.HasPrefix(answer, "PATCH /api/v9/webhooks/app/token/messages/@original ") {
+		t.Fatalf("answer is %q, want it to replace the loading message", answer)
+	}
+
+	if _, ok := UserProfiles.Get(ProfileKeyOf("guild", "newcomer")); !ok {
+		t.Fatal("no profile was created for the first-time player")
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"discordgo-blackjack/blackjack"
+	"discordgo-blackjack/cards"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// JoinWindow is how long players have to sit down and bet before a multi-seat round is dealt
+const JoinWindow = 30 * time.Second
+
+// Table holds the state of the blackjack table running in a channel
+// NOTE: Lock the table before reading or changing any game state, button presses arrive on separate goroutines
+type Table struct {
+	sync.Mutex
+
+	ChannelID string // Channel the game is being played in
+	GuildID   string // Guild the channel belongs to
+	MessageID string // ID of the table embed with the action buttons
+	HostID    string // User that opened the table
+
+	Names map[string]string     // Seated player id -> username shown at the table
+	Keys  map[string]ProfileKey // Seated player id -> profile their bets were taken from
+
+	Shoe       *cards.Shoe // Shoe dealt from across rounds, nil until the first round is dealt
+	ShoeID     int64       // Id the shoe's seeds were saved under, 0 if they couldn't be saved
+	ClientSeed string      // Seed the players chose for the next provably fair shuffle
+
+	// Server seed the next shoe is shuffled with. Its hash is published before a client seed can be set for it.
+	NextServerSeed string
+
+	Round *blackjack.Round // Round being bet on or played, nil between rounds
+	Embed *discordgo.MessageEmbed
+
+	Started bool        // Round has been dealt and is being played
+	Closed  bool        // Table was removed from its channel
+	dealing *time.Timer // Deals the round when the join window closes
+}
+
+// PlayerKey returns the key of the profile the user bets from at this table. A seated player keeps the profile
+// they sat down with for the whole round, even if the guild switches wallets before it is settled.
+func (table *Table) PlayerKey(userID string) ProfileKey {
+	if key, ok := table.Keys[userID]; ok {
+		return key
+	}
+
+	return ProfileKeyOf(table.GuildID, userID)
+}
+
+// TableRegistry keeps track of the active table in each channel
+type TableRegistry struct {
+	mu       sync.Mutex
+	tables   map[string]*Table // channel id -> table
+	messages map[string]string // embed message id -> channel id
+}
+
+// NewTableRegistry returns an empty table registry
+func NewTableRegistry() *TableRegistry {
+	return &TableRegistry{
+		tables:   make(map[string]*Table),
+		messages: make(map[string]string),
+	}
+}
+
+// Open creates a new table for the channel. Returns false if a table is already open there.
+// The new table is returned locked so no button press can touch it before it is dealt.
+func (registry *TableRegistry) Open(channelID string, guildID string, hostID string) (*Table, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if table, ok := registry.tables[channelID]; ok {
+		return table, false
+	}
+
+	table := &Table{
+		ChannelID: channelID,
+		GuildID:   guildID,
+		HostID:    hostID,
+		Names:     make(map[string]string),
+		Keys:      make(map[string]ProfileKey),
+
+		NextServerSeed: cards.NewServerSeed(),
+	}
+	table.Lock()
+	registry.tables[channelID] = table
+
+	return table, true
+}
+
+// Bind links the table embed message of the current round to the table so button presses on it can be routed
+func (registry *TableRegistry) Bind(table *Table, messageID string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	// Buttons on the embed of an earlier round are ignored
+	delete(registry.messages, table.MessageID)
+	table.MessageID = messageID
+	registry.messages[messageID] = table.ChannelID
+}
+
+// ByChannel returns the table running in a channel
+func (registry *TableRegistry) ByChannel(channelID string) (*Table, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	table, ok := registry.tables[channelID]
+	return table, ok
+}
+
+// ByMessage returns the table bound to an embed message
+func (registry *TableRegistry) ByMessage(messageID string) (*Table, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	channelID, ok := registry.messages[messageID]
+	if !ok {
+		return nil, false
+	}
+
+	table, ok := registry.tables[channelID]
+	return table, ok
+}
+
+// ByGuild returns every table running in the guild's channels
+func (registry *TableRegistry) ByGuild(guildID string) []*Table {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	var tables []*Table
+	for _, table := range registry.tables {
+		if table.GuildID == guildID {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables
+}
+
+// Close removes the table from the registry so a new table can be opened in its channel
+func (registry *TableRegistry) Close(table *Table) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	table.Closed = true
+
+	// Only remove the table if it is still the one registered for the channel
+	if registry.tables[table.ChannelID] != table {
+		return
+	}
+
+	delete(registry.messages, table.MessageID)
+	delete(registry.tables, table.ChannelID)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// GlobalWallet is the guild id of the profiles shared by every guild that turned the shared wallet on
+const GlobalWallet = "global"
+
+// SharedWallets - Holds the guilds whose players use their shared profile
+var SharedWallets = NewWalletRegistry()
+
+// WalletRegistry keeps track of the guilds that use the shared wallet
+type WalletRegistry struct {
+	mu     sync.RWMutex
+	shared map[string]bool // guild id -> uses the shared wallet
+}
+
+// NewWalletRegistry returns a registry where every guild has its own wallets
+func NewWalletRegistry() *WalletRegistry {
+	return &WalletRegistry{
+		shared: make(map[string]bool),
+	}
+}
+
+// WalletOf returns the guild id the guild's profiles are kept under: GlobalWallet if it uses the shared wallet
+func (registry *WalletRegistry) WalletOf(guildID string) string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if registry.shared[guildID] {
+		return GlobalWallet
+	}
+
+	return guildID
+}
+
+// Set turns the shared wallet on or off for a guild
+func (registry *WalletRegistry) Set(guildID string, shared bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if shared {
+		registry.shared[guildID] = true
+	} else {
+		delete(registry.shared, guildID)
+	}
+}
+
+// SharedWalletCommand Switches the guild between its own profiles and the profiles players share with every
+// guild that has the shared wallet on. Credits, record and rank are not moved between the two.
+func SharedWalletCommand(ctx *CommandContext, setting string) {
+	shared := setting == "on"
+
+	// Seated players are paid back into the wallet their bets came from either way (Table.Keys),
+	// but switching mid-round would show them a different wallet than the one they are playing with
+	for _, table := range Tables.ByGuild(ctx.GuildID) {
+		table.Lock()
+		busy := table.Round != nil
+		table.Unlock()
+
+		if busy {
+			ctx.Reply("Finish the rounds being played in this server before switching wallets.")
+			return
+		}
+	}
+
+	if err := DBController.SaveSharedWallet(ctx.GuildID, shared); err != nil {
+		fmt.Println("Error saving shared wallet setting:", err)
+		ctx.Reply("Couldn't change the wallet setting, try again later.")
+		return
+	}
+
+	SharedWallets.Set(ctx.GuildID, shared)
+
+	if err := LoadGuildPlayers(ctx.Session, ctx.GuildID); err != nil {
+		fmt.Println("Error loading player data for guild", ctx.GuildID, err)
+	}
+
+	if shared {
+		ctx.Reply("Players in this server now use their shared wallet, the same credits, record and rank in every server with it on.")
+	} else {
+		ctx.Reply("Players in this server now use this server's own wallet.")
+	}
+}